@@ -3,8 +3,12 @@ module furina-bot
 go 1.24.4
 
 require (
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/mattn/go-sqlite3 v1.14.28
+	github.com/tetratelabs/wazero v1.10.1
 	go.mau.fi/whatsmeow v0.0.0-20250709212552-0b8557ee0860
+	golang.org/x/image v0.28.0
+	google.golang.org/protobuf v1.36.6
 )
 
 require (
@@ -22,5 +26,4 @@ require (
 	golang.org/x/net v0.41.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.26.0 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
@@ -0,0 +1,518 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ArgType adalah tipe nilai argumen atau flag command
+type ArgType int
+
+const (
+	// ArgString adalah satu kata, atau beberapa kata di dalam tanda kutip
+	ArgString ArgType = iota
+
+	// ArgInt adalah bilangan bulat
+	ArgInt
+
+	// ArgDuration adalah durasi seperti 30s, 5m, 1h30m atau 2d
+	ArgDuration
+
+	// ArgJID adalah user dari @mention atau nomor HP
+	ArgJID
+
+	// ArgRest adalah seluruh sisa teks apa adanya; hanya boleh di posisi terakhir
+	ArgRest
+
+	// ArgBool adalah flag tanpa nilai (--force) atau dengan nilai (--force=false)
+	ArgBool
+)
+
+// placeholder mengembalikan nama tipe untuk teks pemakaian
+func (t ArgType) placeholder() string {
+	switch t {
+	case ArgInt:
+		return "angka"
+	case ArgDuration:
+		return "durasi"
+	case ArgJID:
+		return "@user"
+	default:
+		return "teks"
+	}
+}
+
+// ArgSpec mendeskripsikan satu argumen posisional command
+type ArgSpec struct {
+	// Name adalah nama argumen untuk Params dan teks pemakaian
+	Name string
+
+	// Type adalah tipe nilai argumen (default ArgString)
+	Type ArgType
+
+	// Optional menandai argumen boleh tidak diisi; hanya boleh setelah argumen wajib
+	Optional bool
+
+	// Description adalah penjelasan singkat argumen untuk menu bantuan
+	Description string
+}
+
+// FlagSpec mendeskripsikan satu flag --nama milik command
+type FlagSpec struct {
+	// Name adalah nama flag tanpa "--"
+	Name string
+
+	// Type adalah tipe nilai flag. ArgBool untuk flag tanpa nilai,
+	// tipe lain dipakai sebagai --nama nilai atau --nama=nilai.
+	Type ArgType
+
+	// Description adalah penjelasan singkat flag untuk menu bantuan
+	Description string
+}
+
+// UsageText mengembalikan format argumen command. Usage dipakai jika diisi,
+// jika tidak dibuat otomatis dari Args, Flags atau Subcommands.
+func (s CommandSpec) UsageText() string {
+	return usageText(s.Usage, s.Args, s.Flags, s.Subcommands)
+}
+
+// usageText menyusun format argumen dari skema jika usage kosong
+func usageText(usage string, args []ArgSpec, flags []FlagSpec, subcommands []Subcommand) string {
+	if usage != "" {
+		return usage
+	}
+
+	var parts []string
+	if len(subcommands) > 0 {
+		names := make([]string, len(subcommands))
+		for i, sub := range subcommands {
+			names[i] = sub.Name
+		}
+		parts = append(parts, "<"+strings.Join(names, " | ")+">")
+	}
+	for _, arg := range args {
+		name := arg.Name
+		if arg.Type == ArgRest {
+			name += "..."
+		}
+		if arg.Optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	for _, flag := range flags {
+		if flag.Type == ArgBool {
+			parts = append(parts, fmt.Sprintf("[--%s]", flag.Name))
+		} else {
+			parts = append(parts, fmt.Sprintf("[--%s <%s>]", flag.Name, flag.Type.placeholder()))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// argErrorKind adalah jenis kesalahan argumen
+type argErrorKind int
+
+const (
+	argMissing argErrorKind = iota
+	argInvalid
+	argTooMany
+	argUnknownFlag
+	argMissingValue
+)
+
+// ArgError dikembalikan saat argumen command tidak sesuai skema
+type ArgError struct {
+	kind  argErrorKind
+	Name  string
+	Value string
+	Type  ArgType
+}
+
+// Error mengimplementasikan interface error
+func (e *ArgError) Error() string {
+	switch e.kind {
+	case argMissing:
+		return fmt.Sprintf("missing argument %s", e.Name)
+	case argInvalid:
+		return fmt.Sprintf("invalid value %q for %s", e.Value, e.Name)
+	case argTooMany:
+		return fmt.Sprintf("unexpected argument %q", e.Value)
+	case argUnknownFlag:
+		return fmt.Sprintf("unknown flag --%s", e.Name)
+	default:
+		return fmt.Sprintf("flag --%s needs a value", e.Name)
+	}
+}
+
+// Message mengembalikan penjelasan kesalahan untuk user
+func (e *ArgError) Message() string {
+	switch e.kind {
+	case argMissing:
+		return fmt.Sprintf("Argumen *%s* wajib diisi.", e.Name)
+	case argTooMany:
+		return fmt.Sprintf("Terlalu banyak argumen: \"%s\".", e.Value)
+	case argUnknownFlag:
+		return fmt.Sprintf("Flag *--%s* tidak dikenal.", e.Name)
+	case argMissingValue:
+		return fmt.Sprintf("Flag *--%s* butuh nilai.", e.Name)
+	}
+
+	switch e.Type {
+	case ArgInt:
+		return fmt.Sprintf("*%s* harus berupa angka, bukan \"%s\".", e.Name, e.Value)
+	case ArgDuration:
+		return fmt.Sprintf("*%s* harus berupa durasi seperti 30s, 5m atau 1h30m, bukan \"%s\".", e.Name, e.Value)
+	case ArgJID:
+		return fmt.Sprintf("*%s* harus berupa mention (@user) atau nomor HP, bukan \"%s\".", e.Name, e.Value)
+	case ArgBool:
+		return fmt.Sprintf("*%s* harus berupa true atau false, bukan \"%s\".", e.Name, e.Value)
+	default:
+		return fmt.Sprintf("Nilai \"%s\" tidak valid untuk *%s*.", e.Value, e.Name)
+	}
+}
+
+// Params menyimpan nilai argumen dan flag yang sudah di-parse sesuai tipenya.
+// Getter mengembalikan nilai nol jika argumen tidak diisi.
+type Params struct {
+	values map[string]interface{}
+}
+
+// Has mengecek apakah argumen atau flag diisi user
+func (p *Params) Has(name string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.values[name]
+	return ok
+}
+
+// String mengembalikan nilai argumen ArgString atau ArgRest
+func (p *Params) String(name string) string {
+	value, _ := p.get(name).(string)
+	return value
+}
+
+// Int mengembalikan nilai argumen ArgInt
+func (p *Params) Int(name string) int {
+	value, _ := p.get(name).(int)
+	return value
+}
+
+// Duration mengembalikan nilai argumen ArgDuration
+func (p *Params) Duration(name string) time.Duration {
+	value, _ := p.get(name).(time.Duration)
+	return value
+}
+
+// JID mengembalikan nilai argumen ArgJID
+func (p *Params) JID(name string) types.JID {
+	value, _ := p.get(name).(types.JID)
+	return value
+}
+
+// Bool mengembalikan nilai flag ArgBool
+func (p *Params) Bool(name string) bool {
+	value, _ := p.get(name).(bool)
+	return value
+}
+
+// get mengambil nilai mentah argumen
+func (p *Params) get(name string) interface{} {
+	if p == nil {
+		return nil
+	}
+	return p.values[name]
+}
+
+// argToken adalah satu argumen hasil tokenize beserta posisinya di teks argumen
+type argToken struct {
+	Value  string
+	Start  int
+	Quoted bool
+}
+
+// closingQuote mengembalikan pasangan penutup tanda kutip, atau 0 jika r bukan tanda kutip pembuka.
+// Kutip miring dari keyboard ponsel juga didukung.
+func closingQuote(r rune) rune {
+	switch r {
+	case '"':
+		return '"'
+	case '“':
+		return '”'
+	default:
+		return 0
+	}
+}
+
+// tokenize memecah teks argumen berdasarkan spasi dengan memperhatikan tanda kutip.
+// Tanda kutip hanya dikenali di awal kata agar apostrof tetap utuh, dan \" di dalam
+// kutip menghasilkan tanda kutip literal. Kutip yang tidak ditutup berlaku sampai akhir teks.
+func tokenize(text string) []argToken {
+	var tokens []argToken
+	var current strings.Builder
+	var quote rune
+	inToken, quoted, escaped := false, false, false
+	start := 0
+
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, argToken{Value: current.String(), Start: start, Quoted: quoted})
+		}
+		current.Reset()
+		inToken, quoted = false, false
+	}
+
+	for i, r := range text {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			switch r {
+			case '\\':
+				escaped = true
+			case closingQuote(quote):
+				quote = 0
+			default:
+				current.WriteRune(r)
+			}
+		case unicode.IsSpace(r):
+			flush()
+		case !inToken && closingQuote(r) != 0:
+			inToken, quoted, start = true, true, i
+			quote = r
+		default:
+			if !inToken {
+				inToken, start = true, i
+			}
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// parseParams mem-parse token argumen sesuai skema command
+func parseParams(args []ArgSpec, flags []FlagSpec, text string, tokens []argToken, mentions []types.JID) (*Params, error) {
+	params := &Params{values: make(map[string]interface{})}
+	position := 0
+	flagsDone := false
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if !flagsDone && !token.Quoted && strings.HasPrefix(token.Value, "--") {
+			if token.Value == "--" {
+				flagsDone = true
+				continue
+			}
+
+			name, value, hasValue := strings.Cut(token.Value[2:], "=")
+			flag, ok := findFlag(flags, name)
+			if !ok {
+				return nil, &ArgError{kind: argUnknownFlag, Name: name}
+			}
+
+			switch {
+			case flag.Type == ArgBool && !hasValue:
+				value = "true"
+			case !hasValue:
+				if i+1 >= len(tokens) {
+					return nil, &ArgError{kind: argMissingValue, Name: flag.Name}
+				}
+				i++
+				value = tokens[i].Value
+			}
+
+			parsed, err := convertArg("--"+flag.Name, flag.Type, value, mentions)
+			if err != nil {
+				return nil, err
+			}
+			params.values[flag.Name] = parsed
+			continue
+		}
+
+		if position >= len(args) {
+			return nil, &ArgError{kind: argTooMany, Value: token.Value}
+		}
+		spec := args[position]
+		position++
+
+		if spec.Type == ArgRest {
+			// Satu argumen berkutip dipakai tanpa kutipnya, selebihnya teks apa adanya
+			rest := strings.TrimSpace(text[token.Start:])
+			if i == len(tokens)-1 {
+				rest = token.Value
+			}
+			params.values[spec.Name] = rest
+			break
+		}
+
+		parsed, err := convertArg(spec.Name, spec.Type, token.Value, mentions)
+		if err != nil {
+			return nil, err
+		}
+		params.values[spec.Name] = parsed
+	}
+
+	for ; position < len(args); position++ {
+		if !args[position].Optional {
+			return nil, &ArgError{kind: argMissing, Name: args[position].Name}
+		}
+	}
+
+	return params, nil
+}
+
+// findFlag mencari flag berdasarkan nama
+func findFlag(flags []FlagSpec, name string) (FlagSpec, bool) {
+	for _, flag := range flags {
+		if strings.EqualFold(flag.Name, name) {
+			return flag, true
+		}
+	}
+	return FlagSpec{}, false
+}
+
+// convertArg mengubah teks argumen menjadi nilai sesuai tipenya
+func convertArg(name string, argType ArgType, value string, mentions []types.JID) (interface{}, error) {
+	invalid := &ArgError{kind: argInvalid, Name: name, Value: value, Type: argType}
+
+	switch argType {
+	case ArgInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, invalid
+		}
+		return n, nil
+	case ArgDuration:
+		d, err := ParseDuration(value)
+		if err != nil {
+			return nil, invalid
+		}
+		return d, nil
+	case ArgJID:
+		jid, err := parseMention(value, mentions)
+		if err != nil {
+			return nil, invalid
+		}
+		return jid, nil
+	case ArgBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, invalid
+		}
+		return b, nil
+	default:
+		return value, nil
+	}
+}
+
+// parseMention mengubah @mention atau nomor HP menjadi JID user. Mention dicocokkan
+// dengan daftar JID yang di-mention di pesan agar mention LID juga dikenali.
+func parseMention(value string, mentions []types.JID) (types.JID, error) {
+	user := strings.TrimPrefix(value, "@")
+	for _, jid := range mentions {
+		if jid.User == user {
+			return jid.ToNonAD(), nil
+		}
+	}
+	return ParseUserJID(user)
+}
+
+// durationUnits adalah satuan yang dikenali ParseDuration
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// ParseDuration mengurai durasi seperti "30s", "5m", "1h30m" atau "2d".
+// Angka tanpa satuan dianggap detik.
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, errors.New("empty duration")
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		if n <= 0 {
+			return 0, fmt.Errorf("duration must be positive")
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+
+	var total time.Duration
+	for value != "" {
+		digits := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+		if digits <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		n, err := strconv.Atoi(value[:digits])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %v", value, err)
+		}
+
+		unit, ok := durationUnits[value[digits:digits+1]]
+		if !ok {
+			return 0, fmt.Errorf("unknown duration unit %q", value[digits:digits+1])
+		}
+		total += time.Duration(n) * unit
+		value = value[digits+1:]
+	}
+
+	if total <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return total, nil
+}
+
+// ReplyUsage membalas dengan alasan kesalahan dan cara pemakaian command
+// atau subcommand yang sedang dijalankan
+func (c *Context) ReplyUsage(reason string) error {
+	usage := c.Prefix + c.Command.Name
+	text := c.Command.UsageText()
+	if c.Subcommand != nil {
+		usage += " " + strings.Join(c.SubcommandPath, " ")
+		text = c.Subcommand.UsageText()
+	}
+	if text != "" {
+		usage += " " + text
+	}
+	return c.Replyf("❌ %s\n\n📝 Pemakaian: `%s`\n💡 Ketik `%smenu %s` untuk detail.", reason, usage, c.Prefix, c.Command.Name)
+}
+
+// argsGuard mem-parse argumen sesuai skema command atau subcommand sebelum plugin
+// dijalankan dan membalas dengan cara pemakaian jika argumen tidak valid
+func (pm *PluginManager) argsGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		args, flags := ctx.Command.Args, ctx.Command.Flags
+		if ctx.Subcommand != nil {
+			args, flags = ctx.Subcommand.Args, ctx.Subcommand.Flags
+		}
+		if len(args) == 0 && len(flags) == 0 {
+			return next(ctx)
+		}
+
+		params, err := parseParams(args, flags, ctx.argText, ctx.tokens, mentionedJIDs(ctx.Event.Message))
+		var argErr *ArgError
+		if errors.As(err, &argErr) {
+			return ctx.ReplyUsage(argErr.Message())
+		}
+		if err != nil {
+			return err
+		}
+
+		ctx.Params = params
+		return next(ctx)
+	}
+}
@@ -0,0 +1,317 @@
+package lib
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []argToken
+	}{
+		{
+			name: "kosong",
+			text: "   ",
+			want: nil,
+		},
+		{
+			name: "dipisah spasi",
+			text: "satu  dua\ttiga",
+			want: []argToken{
+				{Value: "satu", Start: 0},
+				{Value: "dua", Start: 6},
+				{Value: "tiga", Start: 10},
+			},
+		},
+		{
+			name: "kutip ganda",
+			text: `say "halo dunia" lagi`,
+			want: []argToken{
+				{Value: "say", Start: 0},
+				{Value: "halo dunia", Start: 4, Quoted: true},
+				{Value: "lagi", Start: 17},
+			},
+		},
+		{
+			name: "kutip miring dari ponsel",
+			text: "“halo dunia”",
+			want: []argToken{
+				{Value: "halo dunia", Start: 0, Quoted: true},
+			},
+		},
+		{
+			name: "escape kutip di dalam kutip",
+			text: `"kata \"kutip\" di sini"`,
+			want: []argToken{
+				{Value: `kata "kutip" di sini`, Start: 0, Quoted: true},
+			},
+		},
+		{
+			name: "escape backslash di dalam kutip",
+			text: `"a\\b"`,
+			want: []argToken{
+				{Value: `a\b`, Start: 0, Quoted: true},
+			},
+		},
+		{
+			name: "backslash di luar kutip apa adanya",
+			text: `C:\temp`,
+			want: []argToken{
+				{Value: `C:\temp`, Start: 0},
+			},
+		},
+		{
+			name: "apostrof tetap utuh",
+			text: "jum'at it's",
+			want: []argToken{
+				{Value: "jum'at", Start: 0},
+				{Value: "it's", Start: 7},
+			},
+		},
+		{
+			name: "kutip di tengah kata bukan pembuka",
+			text: `a"b c"`,
+			want: []argToken{
+				{Value: `a"b`, Start: 0},
+				{Value: `c"`, Start: 4},
+			},
+		},
+		{
+			name: "kutip tidak ditutup sampai akhir teks",
+			text: `"belum selesai`,
+			want: []argToken{
+				{Value: "belum selesai", Start: 0, Quoted: true},
+			},
+		},
+		{
+			name: "kutip kosong tetap satu argumen",
+			text: `"" x`,
+			want: []argToken{
+				{Value: "", Start: 0, Quoted: true},
+				{Value: "x", Start: 3},
+			},
+		},
+		{
+			name: "kutip menempel dengan teks setelahnya",
+			text: `"ab"cd`,
+			want: []argToken{
+				{Value: "abcd", Start: 0, Quoted: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tokenize(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	args := []ArgSpec{
+		{Name: "jumlah", Type: ArgInt},
+		{Name: "durasi", Type: ArgDuration, Optional: true},
+		{Name: "alasan", Type: ArgRest, Optional: true},
+	}
+	flags := []FlagSpec{
+		{Name: "force", Type: ArgBool},
+		{Name: "label", Type: ArgString},
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		want     map[string]interface{}
+		wantKind argErrorKind
+		wantErr  bool
+	}{
+		{
+			name: "hanya argumen wajib",
+			text: "3",
+			want: map[string]interface{}{"jumlah": 3},
+		},
+		{
+			name: "argumen opsional dan sisa teks",
+			text: "3 5m spam   berulang kali",
+			want: map[string]interface{}{
+				"jumlah": 3,
+				"durasi": 5 * time.Minute,
+				"alasan": "spam   berulang kali",
+			},
+		},
+		{
+			name: "sisa teks satu argumen berkutip tanpa kutip",
+			text: `3 5m "spam berat"`,
+			want: map[string]interface{}{
+				"jumlah": 3,
+				"durasi": 5 * time.Minute,
+				"alasan": "spam berat",
+			},
+		},
+		{
+			name: "sisa teks lebih dari satu argumen apa adanya",
+			text: `3 5m "spam" lagi`,
+			want: map[string]interface{}{
+				"jumlah": 3,
+				"durasi": 5 * time.Minute,
+				"alasan": `"spam" lagi`,
+			},
+		},
+		{
+			name: "flag bool tanpa nilai",
+			text: "--force 3",
+			want: map[string]interface{}{"jumlah": 3, "force": true},
+		},
+		{
+			name: "flag bool dengan nilai",
+			text: "3 --force=false",
+			want: map[string]interface{}{"jumlah": 3, "force": false},
+		},
+		{
+			name: "flag dengan nilai terpisah dan berkutip",
+			text: `3 --label "dua kata"`,
+			want: map[string]interface{}{"jumlah": 3, "label": "dua kata"},
+		},
+		{
+			name: "nama flag tidak peka huruf besar",
+			text: "3 --FORCE",
+			want: map[string]interface{}{"jumlah": 3, "force": true},
+		},
+		{
+			name: "flag berkutip dianggap argumen",
+			text: `3 5m "--force"`,
+			want: map[string]interface{}{
+				"jumlah": 3,
+				"durasi": 5 * time.Minute,
+				"alasan": "--force",
+			},
+		},
+		{
+			name: "setelah -- bukan flag lagi",
+			text: "-- 3 5m --force",
+			want: map[string]interface{}{
+				"jumlah": 3,
+				"durasi": 5 * time.Minute,
+				"alasan": "--force",
+			},
+		},
+		{
+			name:     "argumen wajib kosong",
+			text:     "",
+			wantErr:  true,
+			wantKind: argMissing,
+		},
+		{
+			name:     "bukan angka",
+			text:     "tiga",
+			wantErr:  true,
+			wantKind: argInvalid,
+		},
+		{
+			name:     "durasi tidak valid",
+			text:     "3 5x",
+			wantErr:  true,
+			wantKind: argInvalid,
+		},
+		{
+			name:     "flag tidak dikenal",
+			text:     "3 --verbose",
+			wantErr:  true,
+			wantKind: argUnknownFlag,
+		},
+		{
+			name:     "flag tanpa nilai",
+			text:     "3 --label",
+			wantErr:  true,
+			wantKind: argMissingValue,
+		},
+		{
+			name:     "nilai bool tidak valid",
+			text:     "3 --force=mungkin",
+			wantErr:  true,
+			wantKind: argInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := parseParams(args, flags, tt.text, tokenize(tt.text), nil)
+			if tt.wantErr {
+				var argErr *ArgError
+				if !errors.As(err, &argErr) {
+					t.Fatalf("parseParams(%q) error = %v, want *ArgError", tt.text, err)
+				}
+				if argErr.kind != tt.wantKind {
+					t.Errorf("parseParams(%q) error kind = %d, want %d", tt.text, argErr.kind, tt.wantKind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseParams(%q) error = %v", tt.text, err)
+			}
+			if !reflect.DeepEqual(params.values, tt.want) {
+				t.Errorf("parseParams(%q) = %v, want %v", tt.text, params.values, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseParamsTooMany(t *testing.T) {
+	args := []ArgSpec{{Name: "nama"}}
+	text := "satu dua"
+
+	_, err := parseParams(args, nil, text, tokenize(text), nil)
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.kind != argTooMany || argErr.Value != "dua" {
+		t.Fatalf("parseParams(%q) error = %v, want too many arguments at \"dua\"", text, err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30", want: 30 * time.Second},
+		{value: "30s", want: 30 * time.Second},
+		{value: "5m", want: 5 * time.Minute},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "2d", want: 48 * time.Hour},
+		{value: " 1D12H ", want: 36 * time.Hour},
+		{value: "0", wantErr: true},
+		{value: "-5", wantErr: true},
+		{value: "0m", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "m", wantErr: true},
+		{value: "5", want: 5 * time.Second},
+		{value: "5x", wantErr: true},
+		{value: "1h30", wantErr: true},
+		{value: "1.5h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDuration(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDuration(%q) = %v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration(%q) error = %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
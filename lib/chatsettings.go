@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ChatSettings menyimpan pengaturan bebas per chat dalam bentuk key-value,
+// misalnya untuk mematikan saran command di grup yang ramai.
+// Semua pengaturan dimuat ke memori saat start.
+type ChatSettings struct {
+	db *sql.DB
+
+	mu     sync.RWMutex
+	values map[string]map[string]string
+}
+
+// NewChatSettings membuat instance baru ChatSettings dan memuat pengaturan dari database
+func NewChatSettings(db *sql.DB) (*ChatSettings, error) {
+	cs := &ChatSettings{
+		db:     db,
+		values: make(map[string]map[string]string),
+	}
+
+	if err := cs.initializeTable(); err != nil {
+		return nil, err
+	}
+	if err := cs.load(); err != nil {
+		return nil, err
+	}
+
+	return cs, nil
+}
+
+// initializeTable membuat tabel pengaturan chat jika belum ada
+func (cs *ChatSettings) initializeTable() error {
+	_, err := cs.db.Exec(`CREATE TABLE IF NOT EXISTS furina_chat_settings (
+		chat  TEXT NOT NULL,
+		key   TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (chat, key)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create chat settings table: %v", err)
+	}
+	return nil
+}
+
+// load memuat semua pengaturan chat dari database
+func (cs *ChatSettings) load() error {
+	rows, err := cs.db.Query(`SELECT chat, key, value FROM furina_chat_settings`)
+	if err != nil {
+		return fmt.Errorf("failed to load chat settings: %v", err)
+	}
+	defer rows.Close()
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for rows.Next() {
+		var chat, key, value string
+		if err := rows.Scan(&chat, &key, &value); err != nil {
+			return fmt.Errorf("failed to read chat setting: %v", err)
+		}
+		if cs.values[chat] == nil {
+			cs.values[chat] = make(map[string]string)
+		}
+		cs.values[chat][key] = value
+	}
+	return rows.Err()
+}
+
+// Get mengambil nilai pengaturan chat
+func (cs *ChatSettings) Get(chat types.JID, key string) (string, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	value, ok := cs.values[chatKey(chat)][key]
+	return value, ok
+}
+
+// Set menyimpan nilai pengaturan chat
+func (cs *ChatSettings) Set(chat types.JID, key, value string) error {
+	chatID := chatKey(chat)
+
+	_, err := cs.db.Exec(`INSERT INTO furina_chat_settings (chat, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (chat, key) DO UPDATE SET value = excluded.value`, chatID, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to save chat setting %s: %v", key, err)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.values[chatID] == nil {
+		cs.values[chatID] = make(map[string]string)
+	}
+	cs.values[chatID][key] = value
+	return nil
+}
+
+// Delete menghapus pengaturan chat sehingga kembali ke nilai default
+func (cs *ChatSettings) Delete(chat types.JID, key string) error {
+	chatID := chatKey(chat)
+
+	_, err := cs.db.Exec(`DELETE FROM furina_chat_settings WHERE chat = ? AND key = ?`, chatID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete chat setting %s: %v", key, err)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	delete(cs.values[chatID], key)
+	if len(cs.values[chatID]) == 0 {
+		delete(cs.values, chatID)
+	}
+	return nil
+}
+
+// Bool mengambil pengaturan boolean, atau fallback jika belum diatur
+func (cs *ChatSettings) Bool(chat types.JID, key string, fallback bool) bool {
+	value, ok := cs.Get(chat, key)
+	if !ok {
+		return fallback
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return enabled
+}
+
+// SetBool menyimpan pengaturan boolean
+func (cs *ChatSettings) SetBool(chat types.JID, key string, value bool) error {
+	return cs.Set(chat, key, strconv.FormatBool(value))
+}
+
+// SetChatSettings mengaktifkan pengaturan per chat
+func (pm *PluginManager) SetChatSettings(settings *ChatSettings) {
+	pm.chatSettings = settings
+}
+
+// ChatSettings mengembalikan pengaturan per chat yang aktif (bisa nil)
+func (pm *PluginManager) ChatSettings() *ChatSettings {
+	return pm.chatSettings
+}
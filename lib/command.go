@@ -2,6 +2,7 @@ package lib
 
 import (
 	"strings"
+	"unicode"
 )
 
 // CommandConfig konfigurasi untuk sistem command
@@ -31,31 +32,55 @@ func NewCommandParser(config *CommandConfig) *CommandParser {
 	return &CommandParser{config: config}
 }
 
-// ParseCommand mengurai pesan menjadi command dan arguments
+// ParseCommand mengurai pesan menjadi command dan arguments.
+// Teks di dalam tanda kutip dihitung sebagai satu argumen.
 func (cp *CommandParser) ParseCommand(message string) (command string, args []string, isCommand bool) {
+	command, _, tokens, isCommand := cp.parse(message)
+	if !isCommand {
+		return "", nil, false
+	}
+
+	args = make([]string, len(tokens))
+	for i, token := range tokens {
+		args[i] = token.Value
+	}
+	return command, args, true
+}
+
+// parse mengurai pesan menjadi nama command, teks argumen mentah dan token argumen
+func (cp *CommandParser) parse(message string) (command string, argText string, tokens []argToken, isCommand bool) {
 	message = strings.TrimSpace(message)
-	
+
 	// Cek apakah pesan dimulai dengan prefix
 	if !strings.HasPrefix(message, cp.config.Prefix) {
-		return "", nil, false
+		return "", "", nil, false
 	}
 
 	// Hapus prefix
 	message = message[len(cp.config.Prefix):]
-	
-	// Split menjadi command dan arguments
-	parts := strings.Fields(message)
-	if len(parts) == 0 {
-		return "", nil, false
+
+	// Nama command adalah kata pertama, sisanya teks argumen
+	message = strings.TrimLeftFunc(message, unicode.IsSpace)
+	if message == "" {
+		return "", "", nil, false
+	}
+	end := strings.IndexFunc(message, unicode.IsSpace)
+	if end < 0 {
+		end = len(message)
 	}
 
-	command = parts[0]
+	command = message[:end]
 	if !cp.config.CaseSensitive {
 		command = strings.ToLower(command)
 	}
 
-	args = parts[1:]
-	return command, args, true
+	argText = strings.TrimSpace(message[end:])
+	return command, argText, tokenize(argText), true
+}
+
+// Prefix mengembalikan prefix command yang dikonfigurasi
+func (cp *CommandParser) Prefix() string {
+	return cp.config.Prefix
 }
 
 // IsCommand mengecek apakah pesan adalah command
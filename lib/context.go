@@ -0,0 +1,144 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Context berisi semua informasi command yang sedang diproses dan
+// helper untuk membalas, sehingga plugin tidak perlu mem-parse ulang pesan
+type Context struct {
+	// Ctx adalah context.Context untuk operasi jaringan selama command berjalan
+	Ctx context.Context
+
+	// Client adalah client WhatsApp yang menerima pesan
+	Client *whatsmeow.Client
+
+	// Manager adalah plugin manager yang meneruskan command ini
+	Manager *PluginManager
+
+	// Event adalah event pesan asli dari whatsmeow
+	Event *events.Message
+
+	// Command adalah entri command di registry yang dipanggil (nil untuk listener)
+	Command *Command
+
+	// Invoked adalah nama atau alias yang diketik user (tanpa prefix)
+	Invoked string
+
+	// Args adalah argumen setelah nama command. Teks di dalam tanda kutip
+	// dihitung sebagai satu argumen.
+	Args []string
+
+	// Params adalah argumen yang sudah di-parse sesuai skema Args dan Flags command
+	// atau subcommand
+	Params *Params
+
+	// Subcommand adalah node subcommand yang dijalankan (nil jika command tanpa subcommand).
+	// Args dan Params hanya berisi argumen setelah nama subcommand.
+	Subcommand *Subcommand
+
+	// Match berisi keyword atau hasil regex (beserta grupnya) yang memicu listener.
+	// Selalu nil untuk command.
+	Match []string
+
+	// Conversation adalah percakapan yang sedang berjalan saat pesan diteruskan ke
+	// langkah flow (nil untuk command dan listener)
+	Conversation *Conversation
+
+	// SubcommandPath adalah nama subcommand dari akar sampai Subcommand, misalnya ["settings", "lock"]
+	SubcommandPath []string
+
+	// RawText adalah teks pesan lengkap seperti yang dikirim user, termasuk caption
+	// media dan teks terbaru dari pesan yang diedit
+	RawText string
+
+	// Quoted adalah pesan yang dibalas user (nil jika pesan bukan balasan)
+	Quoted *QuotedMessage
+
+	// Media adalah gambar, video, audio, dokumen atau stiker di pesan ini
+	// (nil jika pesan bukan media). Isinya baru diunduh saat dibutuhkan.
+	Media *Media
+
+	// Prefix adalah prefix command yang dikonfigurasi bot
+	Prefix string
+
+	// Sender adalah JID pengirim pesan
+	Sender types.JID
+
+	// Chat adalah JID chat tempat pesan dikirim
+	Chat types.JID
+
+	// IsGroup bernilai true jika pesan dikirim di grup
+	IsGroup bool
+
+	role     *Role
+	argText  string
+	tokens   []argToken
+	feedback *commandFeedback
+}
+
+// Role mengembalikan role pengirim command. Tanpa permission manager
+// semua user dianggap RoleMember.
+func (c *Context) Role() Role {
+	if c.role == nil {
+		role := RoleMember
+		if c.Manager != nil && c.Manager.Permissions() != nil {
+			role = c.Manager.Permissions().RoleOf(c.Ctx, c.Event.Info.MessageSource)
+		}
+		c.role = &role
+	}
+	return *c.role
+}
+
+// NewMessage membuat MessageBuilder untuk mengirim pesan ke chat asal command
+func (c *Context) NewMessage() *MessageBuilder {
+	return NewMessage(c.Client, c.Chat)
+}
+
+// NewReply membuat MessageBuilder untuk membalas (mengutip) pesan yang memicu command
+func (c *Context) NewReply() *MessageBuilder {
+	return c.NewMessage().Quote(c.Event)
+}
+
+// Reply mengirim balasan teks dengan quote ke pesan yang memicu command
+func (c *Context) Reply(text string) error {
+	_, err := c.NewReply().Text(text).Send(c.Ctx)
+	return err
+}
+
+// Replyf seperti Reply dengan format ala fmt.Sprintf
+func (c *Context) Replyf(format string, args ...interface{}) error {
+	return c.Reply(fmt.Sprintf(format, args...))
+}
+
+// Send mengirim pesan teks biasa ke chat tanpa quote
+func (c *Context) Send(text string) error {
+	_, err := c.NewMessage().Text(text).Send(c.Ctx)
+	return err
+}
+
+// React memberi reaksi emoji ke pesan yang memicu command.
+// Emoji kosong menghapus reaksi sebelumnya.
+func (c *Context) React(emoji string) error {
+	return c.send(c.Client.BuildReaction(c.Chat, c.Sender, c.Event.Info.ID, emoji))
+}
+
+// SendImage meng-upload gambar lalu mengirimnya sebagai balasan dengan caption
+func (c *Context) SendImage(data []byte, caption string) error {
+	return c.SendMedia(ImageMedia, data, MediaOptions{Caption: caption})
+}
+
+// send mengirim pesan ke chat asal command. Pesan tidak dikirim jika c.Ctx sudah berakhir.
+func (c *Context) send(message *waE2E.Message) error {
+	if err := c.Ctx.Err(); err != nil {
+		return fmt.Errorf("message not sent: %v", err)
+	}
+	_, err := c.Client.SendMessage(c.Ctx, c.Chat, message)
+	return err
+}
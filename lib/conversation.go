@@ -0,0 +1,672 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// DefaultFlowTimeout adalah lama bot menunggu jawaban user di satu langkah flow
+const DefaultFlowTimeout = 5 * time.Minute
+
+// conversationSweepInterval adalah jeda pengecekan percakapan yang kedaluwarsa
+const conversationSweepInterval = 30 * time.Second
+
+// DefaultCancelKeywords adalah kata untuk membatalkan percakapan jika flow tidak
+// mendeklarasikan CancelKeywords sendiri
+var DefaultCancelKeywords = []string{"batal", "cancel"}
+
+// ErrUnknownFlow dikembalikan saat memulai flow atau langkah yang tidak terdaftar
+var ErrUnknownFlow = errors.New("unknown flow")
+
+// FlowStep menangani jawaban user di satu langkah flow. Jawaban ada di ctx.RawText.
+// Langkah memanggil conv.Ask untuk bertanya lagi (bisa ke langkah berbeda sesuai
+// jawaban), conv.End untuk selesai, atau tidak keduanya agar user menjawab ulang
+// langkah yang sama, misalnya setelah membalas bahwa jawabannya tidak valid.
+type FlowStep func(ctx *Context, conv *Conversation) error
+
+// Flow adalah percakapan bertahap, misalnya form pendaftaran, konfirmasi atau kuis.
+// Status percakapan disimpan di database sehingga tetap berjalan setelah bot restart.
+type Flow struct {
+	// Name adalah nama unik flow yang dipakai untuk memulainya
+	Name string
+
+	// Steps adalah handler per nama langkah
+	Steps map[string]FlowStep
+
+	// Timeout adalah lama menunggu jawaban di setiap langkah (0 = DefaultFlowTimeout)
+	Timeout time.Duration
+
+	// CancelKeywords adalah kata untuk membatalkan percakapan (nil = DefaultCancelKeywords)
+	CancelKeywords []string
+
+	// CancelMessage adalah balasan saat user membatalkan (kosong = pesan default)
+	CancelMessage string
+
+	// TimeoutMessage adalah pesan saat waktu menjawab habis (kosong = pesan default)
+	TimeoutMessage string
+}
+
+// FlowProvider diimplementasikan plugin yang mendeklarasikan flow percakapan
+type FlowProvider interface {
+	GetFlows() []Flow
+}
+
+// Conversation adalah percakapan yang sedang berjalan antara bot dan satu user di satu chat
+type Conversation struct {
+	manager *ConversationManager
+	flow    *Flow
+
+	chat      types.JID
+	sender    types.JID
+	step      string
+	data      map[string]string
+	expiresAt time.Time
+	ended     bool
+}
+
+// Flow mengembalikan nama flow percakapan
+func (c *Conversation) Flow() string {
+	return c.flow.Name
+}
+
+// Step mengembalikan langkah yang sedang menunggu jawaban
+func (c *Conversation) Step() string {
+	return c.step
+}
+
+// Get mengambil data yang disimpan di percakapan
+func (c *Conversation) Get(key string) string {
+	return c.data[key]
+}
+
+// Set menyimpan data di percakapan. Data ikut disimpan ke database setelah
+// langkah selesai atau saat Ask dipanggil.
+func (c *Conversation) Set(key, value string) {
+	c.data[key] = value
+}
+
+// Data mengembalikan salinan semua data percakapan
+func (c *Conversation) Data() map[string]string {
+	data := make(map[string]string, len(c.data))
+	for key, value := range c.data {
+		data[key] = value
+	}
+	return data
+}
+
+// Ask mengirim pertanyaan lalu menunggu jawaban user berikutnya di langkah step
+func (c *Conversation) Ask(ctx *Context, prompt string, step string) error {
+	if _, ok := c.flow.Steps[step]; !ok {
+		return fmt.Errorf("flow %s: %w step %s", c.flow.Name, ErrUnknownFlow, step)
+	}
+
+	c.step = step
+	c.ended = false
+	if err := c.manager.save(c); err != nil {
+		return err
+	}
+	return ctx.Reply(prompt)
+}
+
+// Goto langsung menjalankan langkah lain dengan pesan yang sama tanpa menunggu jawaban baru
+func (c *Conversation) Goto(ctx *Context, step string) error {
+	handler, ok := c.flow.Steps[step]
+	if !ok {
+		return fmt.Errorf("flow %s: %w step %s", c.flow.Name, ErrUnknownFlow, step)
+	}
+	c.step = step
+	return handler(ctx, c)
+}
+
+// End mengakhiri percakapan
+func (c *Conversation) End() error {
+	c.ended = true
+	return c.manager.delete(c.chat, c.sender)
+}
+
+// ConversationManager menyimpan flow terdaftar dan percakapan yang sedang berjalan.
+// Pesan dari user yang sedang dalam percakapan diteruskan ke langkah flow dan
+// tidak diproses sebagai command maupun listener.
+type ConversationManager struct {
+	client *whatsmeow.Client
+	db     *sql.DB
+
+	mu            sync.Mutex
+	flows         map[string]*Flow
+	owners        map[string]string
+	conversations map[string]*conversationRecord
+}
+
+// conversationRecord adalah status percakapan yang tersimpan
+type conversationRecord struct {
+	chat      types.JID
+	sender    types.JID
+	flow      string
+	step      string
+	data      map[string]string
+	expiresAt time.Time
+}
+
+// NewConversationManager membuat instance baru ConversationManager dan memuat
+// percakapan yang belum kedaluwarsa dari database
+func NewConversationManager(client *whatsmeow.Client, db *sql.DB) (*ConversationManager, error) {
+	cm := &ConversationManager{
+		client:        client,
+		db:            db,
+		flows:         make(map[string]*Flow),
+		owners:        make(map[string]string),
+		conversations: make(map[string]*conversationRecord),
+	}
+
+	if err := cm.initializeTable(); err != nil {
+		return nil, err
+	}
+	if err := cm.load(); err != nil {
+		return nil, err
+	}
+
+	return cm, nil
+}
+
+// initializeTable membuat tabel percakapan jika belum ada
+func (cm *ConversationManager) initializeTable() error {
+	_, err := cm.db.Exec(`CREATE TABLE IF NOT EXISTS furina_conversations (
+		chat       TEXT NOT NULL,
+		sender     TEXT NOT NULL,
+		flow       TEXT NOT NULL,
+		step       TEXT NOT NULL,
+		data       TEXT NOT NULL,
+		expires_at INTEGER NOT NULL,
+		PRIMARY KEY (chat, sender)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create conversation table: %v", err)
+	}
+	return nil
+}
+
+// load memuat percakapan yang masih berjalan dari database
+func (cm *ConversationManager) load() error {
+	rows, err := cm.db.Query(`SELECT chat, sender, flow, step, data, expires_at FROM furina_conversations`)
+	if err != nil {
+		return fmt.Errorf("failed to load conversations: %v", err)
+	}
+	defer rows.Close()
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for rows.Next() {
+		var chat, sender, data string
+		var expiresAt int64
+		record := &conversationRecord{}
+		if err := rows.Scan(&chat, &sender, &record.flow, &record.step, &data, &expiresAt); err != nil {
+			return fmt.Errorf("failed to read conversation: %v", err)
+		}
+		if record.chat, err = types.ParseJID(chat); err != nil {
+			continue
+		}
+		if record.sender, err = types.ParseJID(sender); err != nil {
+			continue
+		}
+		if err := json.Unmarshal([]byte(data), &record.data); err != nil || record.data == nil {
+			record.data = make(map[string]string)
+		}
+		record.expiresAt = time.UnixMilli(expiresAt)
+		cm.conversations[conversationKey(record.chat, record.sender)] = record
+	}
+	return rows.Err()
+}
+
+// conversationKey membuat kunci percakapan satu user di satu chat
+func conversationKey(chat, sender types.JID) string {
+	return chatKey(chat) + "|" + sender.ToNonAD().String()
+}
+
+// RegisterFlow mendaftarkan flow atas nama plugin owner
+func (cm *ConversationManager) RegisterFlow(owner string, flow Flow) error {
+	if err := validateFlow(owner, flow); err != nil {
+		return err
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if existing, ok := cm.owners[flow.Name]; ok && existing != owner {
+		return fmt.Errorf("flow %s already registered by plugin %s", flow.Name, existing)
+	}
+	cm.flows[flow.Name] = &flow
+	cm.owners[flow.Name] = owner
+	return nil
+}
+
+// CheckFlows memeriksa flow milik plugin owner tanpa mendaftarkannya
+func (cm *ConversationManager) CheckFlows(owner string, flows []Flow) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	return cm.checkFlowsLocked(owner, flows)
+}
+
+// ReplaceFlows mengganti semua flow milik plugin owner sekaligus. Jika ada flow
+// yang tidak valid atau namanya dipakai plugin lain, flow lama tetap dipakai.
+func (cm *ConversationManager) ReplaceFlows(owner string, flows []Flow) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if err := cm.checkFlowsLocked(owner, flows); err != nil {
+		return err
+	}
+
+	for name, flowOwner := range cm.owners {
+		if flowOwner == owner {
+			delete(cm.flows, name)
+			delete(cm.owners, name)
+		}
+	}
+	for _, flow := range flows {
+		cm.flows[flow.Name] = &flow
+		cm.owners[flow.Name] = owner
+	}
+	return nil
+}
+
+// checkFlowsLocked memvalidasi flow dan memastikan namanya tidak dipakai plugin
+// lain atau dipakai dua kali. Harus dipanggil dengan mu terkunci.
+func (cm *ConversationManager) checkFlowsLocked(owner string, flows []Flow) error {
+	seen := make(map[string]bool, len(flows))
+	for _, flow := range flows {
+		if err := validateFlow(owner, flow); err != nil {
+			return err
+		}
+		if seen[flow.Name] {
+			return fmt.Errorf("plugin %s: flow %s is declared twice", owner, flow.Name)
+		}
+		seen[flow.Name] = true
+		if existing, ok := cm.owners[flow.Name]; ok && existing != owner {
+			return fmt.Errorf("flow %s already registered by plugin %s", flow.Name, existing)
+		}
+	}
+	return nil
+}
+
+// validateFlow memeriksa nama dan handler setiap langkah flow
+func validateFlow(owner string, flow Flow) error {
+	if flow.Name == "" || len(flow.Steps) == 0 {
+		return fmt.Errorf("plugin %s: flow %q has no name or steps", owner, flow.Name)
+	}
+	for name, step := range flow.Steps {
+		if step == nil {
+			return fmt.Errorf("plugin %s: flow %s step %s has no handler", owner, flow.Name, name)
+		}
+	}
+	return nil
+}
+
+// UnregisterFlows menghapus semua flow milik plugin owner. Percakapan yang
+// sedang berjalan di flow tersebut berakhir saat user mengirim pesan berikutnya.
+func (cm *ConversationManager) UnregisterFlows(owner string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for name, flowOwner := range cm.owners {
+		if flowOwner == owner {
+			delete(cm.flows, name)
+			delete(cm.owners, name)
+		}
+	}
+}
+
+// Start memulai flow untuk pengirim ctx di chat ctx, menggantikan percakapan
+// lain yang sedang berjalan. Panggil conv.Ask untuk mengirim pertanyaan pertama.
+func (cm *ConversationManager) Start(ctx *Context, flowName string) (*Conversation, error) {
+	cm.mu.Lock()
+	flow, ok := cm.flows[flowName]
+	cm.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownFlow, flowName)
+	}
+
+	return &Conversation{
+		manager: cm,
+		flow:    flow,
+		chat:    ctx.Chat,
+		sender:  ctx.Sender,
+		data:    make(map[string]string),
+	}, nil
+}
+
+// Active mengembalikan nama flow yang sedang berjalan untuk user di chat
+func (cm *ConversationManager) Active(chat, sender types.JID) (string, bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	record, ok := cm.conversations[conversationKey(chat, sender)]
+	if !ok || time.Now().After(record.expiresAt) {
+		return "", false
+	}
+	return record.flow, true
+}
+
+// Cancel mengakhiri percakapan user di chat tanpa balasan
+func (cm *ConversationManager) Cancel(chat, sender types.JID) error {
+	return cm.delete(chat, sender)
+}
+
+// save menyimpan status percakapan dan memperpanjang batas waktunya
+func (cm *ConversationManager) save(conv *Conversation) error {
+	timeout := conv.flow.Timeout
+	if timeout <= 0 {
+		timeout = DefaultFlowTimeout
+	}
+	conv.expiresAt = time.Now().Add(timeout)
+
+	data, err := json.Marshal(conv.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation data: %v", err)
+	}
+
+	_, err = cm.db.Exec(`INSERT INTO furina_conversations (chat, sender, flow, step, data, expires_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chat, sender) DO UPDATE SET flow = excluded.flow, step = excluded.step, data = excluded.data, expires_at = excluded.expires_at`,
+		chatKey(conv.chat), conv.sender.ToNonAD().String(), conv.flow.Name, conv.step, string(data), conv.expiresAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save conversation %s: %v", conv.flow.Name, err)
+	}
+
+	cm.mu.Lock()
+	cm.conversations[conversationKey(conv.chat, conv.sender)] = &conversationRecord{
+		chat:      conv.chat.ToNonAD(),
+		sender:    conv.sender.ToNonAD(),
+		flow:      conv.flow.Name,
+		step:      conv.step,
+		data:      conv.Data(),
+		expiresAt: conv.expiresAt,
+	}
+	cm.mu.Unlock()
+	return nil
+}
+
+// delete menghapus percakapan user di chat
+func (cm *ConversationManager) delete(chat, sender types.JID) error {
+	cm.mu.Lock()
+	delete(cm.conversations, conversationKey(chat, sender))
+	cm.mu.Unlock()
+
+	if _, err := cm.db.Exec(`DELETE FROM furina_conversations WHERE chat = ? AND sender = ?`, chatKey(chat), sender.ToNonAD().String()); err != nil {
+		return fmt.Errorf("failed to delete conversation: %v", err)
+	}
+	return nil
+}
+
+// resume mengambil percakapan aktif user di chat ctx. Percakapan yang kedaluwarsa
+// atau flow-nya sudah tidak terdaftar dihapus.
+func (cm *ConversationManager) resume(ctx *Context) (*Conversation, error) {
+	key := conversationKey(ctx.Chat, ctx.Sender)
+
+	cm.mu.Lock()
+	record, ok := cm.conversations[key]
+	var flow *Flow
+	if ok {
+		flow = cm.flows[record.flow]
+	}
+	cm.mu.Unlock()
+
+	if !ok {
+		return nil, nil
+	}
+	if flow == nil || flow.Steps[record.step] == nil || time.Now().After(record.expiresAt) {
+		return nil, cm.delete(record.chat, record.sender)
+	}
+
+	data := make(map[string]string, len(record.data))
+	for k, v := range record.data {
+		data[k] = v
+	}
+	return &Conversation{
+		manager:   cm,
+		flow:      flow,
+		chat:      record.chat,
+		sender:    record.sender,
+		step:      record.step,
+		data:      data,
+		expiresAt: record.expiresAt,
+	}, nil
+}
+
+// activeOwner mengembalikan plugin pemilik flow percakapan aktif user di chat.
+// owner kosong jika flow-nya sudah tidak terdaftar.
+func (cm *ConversationManager) activeOwner(chat, sender types.JID) (owner string, ok bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	record, ok := cm.conversations[conversationKey(chat, sender)]
+	if !ok {
+		return "", false
+	}
+	return cm.owners[record.flow], true
+}
+
+// isCancel mengecek apakah teks adalah kata pembatal flow, dengan atau tanpa prefix
+func (f *Flow) isCancel(text, prefix string) bool {
+	keywords := f.CancelKeywords
+	if keywords == nil {
+		keywords = DefaultCancelKeywords
+	}
+
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.TrimPrefix(text, prefix)
+	for _, keyword := range keywords {
+		if text == strings.ToLower(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// handle meneruskan pesan ke langkah flow jika pengirim sedang dalam percakapan.
+// Mengembalikan consumed=true jika pesan dipakai oleh flow.
+func (cm *ConversationManager) handle(ctx *Context, timeout time.Duration) (consumed bool, err error) {
+	conv, err := cm.resume(ctx)
+	if conv == nil {
+		return false, err
+	}
+
+	// Langkah flow dibatasi waktu seperti command
+	stepCtx, cancel := context.WithTimeout(ctx.Ctx, timeout)
+	defer cancel()
+	ctx.Ctx = stepCtx
+
+	if conv.flow.isCancel(ctx.RawText, ctx.Prefix) {
+		if err := conv.End(); err != nil {
+			return true, err
+		}
+		message := conv.flow.CancelMessage
+		if message == "" {
+			message = "❌ Percakapan dibatalkan."
+		}
+		return true, ctx.Reply(message)
+	}
+
+	ctx.Conversation = conv
+	step := conv.step
+	if err := runFlowStep(ctx, conv, conv.flow.Steps[step]); err != nil {
+		return true, fmt.Errorf("flow %s step %s: %w", conv.flow.Name, step, err)
+	}
+
+	// Simpan data dan perpanjang waktu jika langkah tidak mengakhiri percakapan
+	if !conv.ended {
+		return true, cm.save(conv)
+	}
+	return true, nil
+}
+
+// runConversation meneruskan pesan ke flow jika pengirim sedang dalam percakapan.
+// User yang diblokir tidak bisa melanjutkan percakapan, jawaban flow ikut rate
+// limit seperti command, dan percakapan milik plugin yang dinonaktifkan di chat
+// ini dibatalkan sehingga pesannya diproses seperti biasa.
+func (pm *PluginManager) runConversation(ctx *Context) (consumed bool, err error) {
+	if pm.conversations == nil {
+		return false, nil
+	}
+	// Vote polling dan reaksi bukan jawaban untuk langkah flow
+	if IsUpdateMessage(ctx.Event.Message) {
+		return false, nil
+	}
+	if pm.permissions != nil && pm.permissions.IsBanned(ctx.Ctx, ctx.Event.Info.MessageSource) {
+		return false, nil
+	}
+
+	owner, ok := pm.conversations.activeOwner(ctx.Chat, ctx.Sender)
+	if !ok {
+		return false, nil
+	}
+	if owner != "" && !pm.IsPluginEnabled(owner, ctx.Chat) {
+		return false, pm.conversations.delete(ctx.Chat, ctx.Sender)
+	}
+	if allowed, err := pm.checkRateLimit(ctx, "", nil); !allowed {
+		return true, err
+	}
+	return pm.conversations.handle(ctx, pm.CommandTimeout(nil))
+}
+
+// runFlowStep menjalankan satu langkah flow dan mengubah panic menjadi error
+func runFlowStep(ctx *Context, conv *Conversation, step FlowStep) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return step(ctx, conv)
+}
+
+// Watch menjalankan pengecekan berkala untuk mengakhiri percakapan yang
+// kedaluwarsa dan memberi tahu user. Berhenti saat ctx dibatalkan.
+func (cm *ConversationManager) Watch(ctx context.Context, errorHandler *ErrorHandler) {
+	go func() {
+		ticker := time.NewTicker(conversationSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := cm.sweep(ctx); err != nil && errorHandler != nil {
+					errorHandler.LogError(err, "ConversationManager.Watch")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// sweep mengakhiri percakapan yang kedaluwarsa
+func (cm *ConversationManager) sweep(ctx context.Context) error {
+	now := time.Now()
+
+	cm.mu.Lock()
+	var expired []*conversationRecord
+	messages := make(map[*conversationRecord]string)
+	for _, record := range cm.conversations {
+		if now.After(record.expiresAt) {
+			expired = append(expired, record)
+			if flow, ok := cm.flows[record.flow]; ok {
+				messages[record] = flow.TimeoutMessage
+			}
+		}
+	}
+	cm.mu.Unlock()
+
+	var errs []error
+	for _, record := range expired {
+		if err := cm.delete(record.chat, record.sender); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := cm.notifyTimeout(ctx, record, messages[record]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyTimeout memberi tahu user bahwa waktu menjawab habis
+func (cm *ConversationManager) notifyTimeout(ctx context.Context, record *conversationRecord, message string) error {
+	if cm.client == nil {
+		return nil
+	}
+	if message == "" {
+		message = "⌛ Waktu menjawab habis, percakapan dibatalkan."
+	}
+
+	text := message
+	var mentions []types.JID
+	if record.chat.Server == types.GroupServer {
+		text = fmt.Sprintf("@%s %s", record.sender.User, message)
+		mentions = append(mentions, record.sender)
+	}
+
+	_, err := NewMessage(cm.client, record.chat).Text(text).Mention(mentions...).Send(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to send conversation timeout to %s: %v", record.chat, err)
+	}
+	return nil
+}
+
+// SetConversationManager mengaktifkan flow percakapan. Plugin yang
+// mengimplementasikan FlowProvider mendaftarkan flow-nya saat didaftarkan.
+func (pm *PluginManager) SetConversationManager(conversations *ConversationManager) {
+	pm.conversations = conversations
+}
+
+// Conversations mengembalikan conversation manager yang aktif (bisa nil)
+func (pm *PluginManager) Conversations() *ConversationManager {
+	return pm.conversations
+}
+
+// flowsOf mengambil flow milik plugin, nil jika plugin tidak punya flow
+func flowsOf(plugin Plugin) []Flow {
+	if provider, ok := plugin.(FlowProvider); ok {
+		return provider.GetFlows()
+	}
+	return nil
+}
+
+// checkFlows memeriksa flow milik plugin tanpa mendaftarkannya
+func (pm *PluginManager) checkFlows(plugin Plugin) error {
+	if pm.conversations == nil {
+		return nil
+	}
+	return pm.conversations.CheckFlows(plugin.GetName(), flowsOf(plugin))
+}
+
+// registerFlows mendaftarkan flow milik plugin, menggantikan flow lamanya
+func (pm *PluginManager) registerFlows(plugin Plugin) error {
+	if pm.conversations == nil {
+		return nil
+	}
+	return pm.conversations.ReplaceFlows(plugin.GetName(), flowsOf(plugin))
+}
+
+// unregisterFlows menghapus flow milik plugin
+func (pm *PluginManager) unregisterFlows(name string) {
+	if pm.conversations != nil {
+		pm.conversations.UnregisterFlows(name)
+	}
+}
+
+// StartFlow memulai flow untuk pengirim pesan ini
+func (c *Context) StartFlow(flow string) (*Conversation, error) {
+	if c.Manager == nil || c.Manager.Conversations() == nil {
+		return nil, fmt.Errorf("conversations are not enabled")
+	}
+	return c.Manager.Conversations().Start(c, flow)
+}
@@ -0,0 +1,166 @@
+package lib
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// CooldownScope menentukan siapa yang berbagi satu cooldown
+type CooldownScope int
+
+const (
+	// CooldownUser berarti cooldown berlaku per pengirim
+	CooldownUser CooldownScope = iota
+
+	// CooldownChat berarti cooldown berlaku per chat untuk semua anggota
+	CooldownChat
+
+	// CooldownGlobal berarti cooldown berlaku untuk semua chat sekaligus
+	CooldownGlobal
+)
+
+// Cooldown mendefinisikan jeda minimum antar pemakaian command
+type Cooldown struct {
+	Duration time.Duration
+	Scope    CooldownScope
+}
+
+// CooldownManager menyimpan waktu berakhirnya cooldown command di database
+// agar tetap berlaku setelah bot di-restart
+type CooldownManager struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewCooldownManager membuat instance baru CooldownManager dan memuat cooldown yang masih aktif
+func NewCooldownManager(db *sql.DB) (*CooldownManager, error) {
+	cm := &CooldownManager{
+		db:      db,
+		expires: make(map[string]time.Time),
+	}
+
+	if err := cm.initializeTable(); err != nil {
+		return nil, err
+	}
+	if err := cm.load(); err != nil {
+		return nil, err
+	}
+
+	return cm, nil
+}
+
+// initializeTable membuat tabel cooldown jika belum ada
+func (cm *CooldownManager) initializeTable() error {
+	_, err := cm.db.Exec(`CREATE TABLE IF NOT EXISTS furina_cooldowns (
+		key        TEXT PRIMARY KEY,
+		expires_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create cooldowns table: %v", err)
+	}
+	return nil
+}
+
+// load membuang cooldown kedaluwarsa lalu memuat sisanya
+func (cm *CooldownManager) load() error {
+	now := time.Now()
+	if _, err := cm.db.Exec(`DELETE FROM furina_cooldowns WHERE expires_at <= ?`, now.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to clean up cooldowns: %v", err)
+	}
+
+	rows, err := cm.db.Query(`SELECT key, expires_at FROM furina_cooldowns`)
+	if err != nil {
+		return fmt.Errorf("failed to load cooldowns: %v", err)
+	}
+	defer rows.Close()
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for rows.Next() {
+		var key string
+		var expiresAt int64
+		if err := rows.Scan(&key, &expiresAt); err != nil {
+			return fmt.Errorf("failed to read cooldown: %v", err)
+		}
+		cm.expires[key] = time.UnixMilli(expiresAt)
+	}
+	return rows.Err()
+}
+
+// cooldownKey membuat kunci cooldown sesuai scope
+func cooldownKey(command string, scope CooldownScope, sender, chat types.JID) string {
+	switch scope {
+	case CooldownChat:
+		return command + "|chat:" + chat.String()
+	case CooldownGlobal:
+		return command + "|global"
+	default:
+		return command + "|user:" + sender.ToNonAD().String()
+	}
+}
+
+// Acquire memulai cooldown jika belum aktif. Jika cooldown masih berjalan,
+// sisa waktunya dikembalikan dan cooldown tidak diperbarui.
+func (cm *CooldownManager) Acquire(command string, cooldown Cooldown, sender, chat types.JID) (time.Duration, error) {
+	if cooldown.Duration <= 0 {
+		return 0, nil
+	}
+
+	key := cooldownKey(command, cooldown.Scope, sender, chat)
+	now := time.Now()
+
+	cm.mu.Lock()
+	if expiresAt, ok := cm.expires[key]; ok && now.Before(expiresAt) {
+		cm.mu.Unlock()
+		return expiresAt.Sub(now), nil
+	}
+
+	expiresAt := now.Add(cooldown.Duration)
+	cm.expires[key] = expiresAt
+	cm.pruneLocked(now)
+	cm.mu.Unlock()
+
+	_, err := cm.db.Exec(`INSERT INTO furina_cooldowns (key, expires_at) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET expires_at = excluded.expires_at`, key, expiresAt.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to save cooldown %s: %v", key, err)
+	}
+	return 0, nil
+}
+
+// pruneLocked membuang cooldown kedaluwarsa dari memori. Harus dipanggil dengan mu terkunci.
+func (cm *CooldownManager) pruneLocked(now time.Time) {
+	for key, expiresAt := range cm.expires {
+		if !now.Before(expiresAt) {
+			delete(cm.expires, key)
+		}
+	}
+}
+
+// FormatDuration menampilkan durasi dalam bahasa Indonesia, misalnya "1 menit 5 detik"
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < time.Second {
+		d = time.Second
+	}
+
+	var parts []string
+	if hours := int(d / time.Hour); hours > 0 {
+		parts = append(parts, fmt.Sprintf("%d jam", hours))
+	}
+	if minutes := int(d % time.Hour / time.Minute); minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%d menit", minutes))
+	}
+	if seconds := int(d % time.Minute / time.Second); seconds > 0 {
+		parts = append(parts, fmt.Sprintf("%d detik", seconds))
+	}
+	return strings.Join(parts, " ")
+}
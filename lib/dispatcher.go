@@ -0,0 +1,323 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var (
+	// ErrDispatcherClosed dikembalikan saat pesan dikirim ke dispatcher yang sudah dihentikan
+	ErrDispatcherClosed = errors.New("dispatcher is shut down")
+
+	// ErrQueueFull dikembalikan saat antrean chat atau antrean global penuh
+	ErrQueueFull = errors.New("dispatch queue is full")
+)
+
+// DispatcherConfig konfigurasi dispatcher pesan
+type DispatcherConfig struct {
+	// Workers adalah jumlah pesan yang diproses bersamaan
+	Workers int
+
+	// ChatQueueSize adalah batas pesan yang mengantre per chat. Pesan baru dari chat
+	// yang antreannya penuh langsung dibuang agar satu chat tidak memonopoli bot.
+	ChatQueueSize int
+
+	// MaxPending adalah batas total pesan yang mengantre dan sedang diproses
+	MaxPending int
+
+	// EnqueueTimeout adalah lama Submit menunggu saat antrean global penuh
+	// sebelum pesan dibuang (backpressure ke event loop whatsmeow)
+	EnqueueTimeout time.Duration
+}
+
+// DefaultDispatcherConfig mengembalikan konfigurasi default dispatcher
+func DefaultDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		Workers:        8,
+		ChatQueueSize:  50,
+		MaxPending:     1000,
+		EnqueueTimeout: 2 * time.Second,
+	}
+}
+
+// DispatcherStats adalah metrik dispatcher saat ini
+type DispatcherStats struct {
+	// Submitted adalah jumlah pesan yang diterima dispatcher
+	Submitted uint64
+
+	// Processed adalah jumlah pesan yang selesai diproses
+	Processed uint64
+
+	// Failed adalah jumlah pesan yang handler-nya mengembalikan error atau panic
+	Failed uint64
+
+	// Dropped adalah jumlah pesan yang dibuang karena antrean penuh
+	Dropped uint64
+
+	// Queued adalah jumlah pesan yang sedang mengantre
+	Queued int
+
+	// Busy adalah jumlah worker yang sedang memproses pesan
+	Busy int
+
+	// Chats adalah jumlah chat yang punya pesan mengantre atau sedang diproses
+	Chats int
+
+	// AverageLatency adalah rata-rata waktu dari pesan diterima sampai selesai diproses
+	AverageLatency time.Duration
+}
+
+// queuedMessage adalah pesan di antrean beserta waktu masuknya
+type queuedMessage struct {
+	message  *events.Message
+	queuedAt time.Time
+}
+
+// chatQueue adalah antrean pesan satu chat
+type chatQueue struct {
+	key      string
+	messages []queuedMessage
+}
+
+// Dispatcher memproses pesan dengan sejumlah worker. Pesan dari chat yang sama
+// diproses berurutan, pesan dari chat berbeda diproses paralel.
+type Dispatcher struct {
+	config       DispatcherConfig
+	handler      func(ctx context.Context, message *events.Message) error
+	errorHandler *ErrorHandler
+
+	// ctx diteruskan ke handler; jika dibatalkan, command yang sedang berjalan
+	// ikut dibatalkan dan pesan yang masih mengantre dibuang
+	ctx context.Context
+
+	// slots membatasi total pesan yang mengantre dan sedang diproses
+	slots chan struct{}
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues map[string]*chatQueue
+	ready  []*chatQueue
+	closed bool
+	wg     sync.WaitGroup
+
+	submitted    atomic.Uint64
+	processed    atomic.Uint64
+	failed       atomic.Uint64
+	dropped      atomic.Uint64
+	busy         atomic.Int64
+	totalLatency atomic.Int64
+}
+
+// NewDispatcher membuat dispatcher dan menjalankan worker-nya. handler biasanya
+// PluginManager.HandleMessage dan menerima ctx untuk setiap pesan.
+func NewDispatcher(ctx context.Context, config DispatcherConfig, handler func(ctx context.Context, message *events.Message) error, errorHandler *ErrorHandler) *Dispatcher {
+	defaults := DefaultDispatcherConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.ChatQueueSize <= 0 {
+		config.ChatQueueSize = defaults.ChatQueueSize
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = defaults.MaxPending
+	}
+
+	d := &Dispatcher{
+		config:       config,
+		handler:      handler,
+		errorHandler: errorHandler,
+		ctx:          ctx,
+		slots:        make(chan struct{}, config.MaxPending),
+		queues:       make(map[string]*chatQueue),
+	}
+	d.cond = sync.NewCond(&d.mu)
+
+	for i := 0; i < config.Workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+	return d
+}
+
+// Submit memasukkan pesan ke antrean chat-nya. Jika antrean global penuh, Submit
+// menunggu paling lama EnqueueTimeout sebelum mengembalikan ErrQueueFull.
+func (d *Dispatcher) Submit(message *events.Message) error {
+	d.submitted.Add(1)
+
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		timer := time.NewTimer(d.config.EnqueueTimeout)
+		defer timer.Stop()
+		select {
+		case d.slots <- struct{}{}:
+		case <-timer.C:
+			d.dropped.Add(1)
+			return fmt.Errorf("%w: %d messages pending", ErrQueueFull, d.config.MaxPending)
+		}
+	}
+
+	key := message.Info.Chat.ToNonAD().String()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		<-d.slots
+		d.dropped.Add(1)
+		return ErrDispatcherClosed
+	}
+
+	queue, active := d.queues[key]
+	if !active {
+		queue = &chatQueue{key: key}
+		d.queues[key] = queue
+	}
+	if len(queue.messages) >= d.config.ChatQueueSize {
+		<-d.slots
+		d.dropped.Add(1)
+		return fmt.Errorf("%w: chat %s has %d messages queued", ErrQueueFull, key, len(queue.messages))
+	}
+
+	queue.messages = append(queue.messages, queuedMessage{message: message, queuedAt: time.Now()})
+
+	// Chat yang belum aktif dijadwalkan; chat aktif akan dijadwalkan ulang oleh worker-nya
+	if !active {
+		d.ready = append(d.ready, queue)
+		d.cond.Signal()
+	}
+	return nil
+}
+
+// worker mengambil satu pesan dari chat yang siap, memprosesnya, lalu
+// menjadwalkan ulang chat tersebut jika masih ada pesan
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		for len(d.ready) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if len(d.ready) == 0 {
+			d.mu.Unlock()
+			return
+		}
+
+		queue := d.ready[0]
+		d.ready = d.ready[1:]
+		item := queue.messages[0]
+		queue.messages = queue.messages[1:]
+		d.mu.Unlock()
+
+		// Setelah ctx dibatalkan sisa antrean dibuang tanpa diproses
+		if d.ctx.Err() != nil {
+			d.dropped.Add(1)
+		} else {
+			d.process(item)
+		}
+		<-d.slots
+
+		d.mu.Lock()
+		if len(queue.messages) > 0 {
+			d.ready = append(d.ready, queue)
+			d.cond.Signal()
+		} else {
+			delete(d.queues, queue.key)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// process menjalankan handler untuk satu pesan dengan recovery panic
+func (d *Dispatcher) process(item queuedMessage) {
+	d.busy.Add(1)
+	defer func() {
+		if r := recover(); r != nil {
+			d.failed.Add(1)
+			d.logError(fmt.Errorf("panic while handling message: %v", r))
+		}
+		d.busy.Add(-1)
+		d.processed.Add(1)
+		d.totalLatency.Add(int64(time.Since(item.queuedAt)))
+	}()
+
+	if err := d.handler(d.ctx, item.message); err != nil {
+		d.failed.Add(1)
+		d.logError(err)
+	}
+}
+
+// logError mencatat error dari handler pesan
+func (d *Dispatcher) logError(err error) {
+	fmt.Printf("❌ Error handling message: %v\n", err)
+	if d.errorHandler != nil {
+		d.errorHandler.LogError(err, "Dispatcher")
+	}
+}
+
+// Stats mengembalikan metrik dispatcher saat ini
+func (d *Dispatcher) Stats() DispatcherStats {
+	d.mu.Lock()
+	queued := 0
+	for _, queue := range d.queues {
+		queued += len(queue.messages)
+	}
+	chats := len(d.queues)
+	d.mu.Unlock()
+
+	stats := DispatcherStats{
+		Submitted: d.submitted.Load(),
+		Processed: d.processed.Load(),
+		Failed:    d.failed.Load(),
+		Dropped:   d.dropped.Load(),
+		Queued:    queued,
+		Busy:      int(d.busy.Load()),
+		Chats:     chats,
+	}
+	if stats.Processed > 0 {
+		stats.AverageLatency = time.Duration(d.totalLatency.Load() / int64(stats.Processed))
+	}
+	return stats
+}
+
+// Shutdown berhenti menerima pesan baru dan menunggu semua antrean selesai diproses.
+// Jika context dispatcher sudah dibatalkan, Shutdown hanya menunggu command yang
+// sedang berjalan berhenti. Jika ctx berakhir lebih dulu, Shutdown kembali dengan
+// error ctx dan sisa pesan tetap diproses di latar belakang; batalkan context
+// dispatcher lalu panggil Shutdown lagi untuk membuang sisa antrean.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("dispatcher did not drain: %v", ctx.Err())
+	}
+}
+
+// SetDispatcher menghubungkan dispatcher agar metriknya bisa dibaca plugin
+func (pm *PluginManager) SetDispatcher(dispatcher *Dispatcher) {
+	pm.dispatcher = dispatcher
+}
+
+// Dispatcher mengembalikan dispatcher yang terhubung (bisa nil)
+func (pm *PluginManager) Dispatcher() *Dispatcher {
+	return pm.dispatcher
+}
@@ -0,0 +1,241 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// testMessage membuat pesan dengan ID tertentu di chat tertentu
+func testMessage(chat string, id int) *events.Message {
+	return &events.Message{Info: types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: types.NewJID(chat, types.DefaultUserServer)},
+		ID:            fmt.Sprint(id),
+	}}
+}
+
+func TestDispatcherChatOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		chats   int
+		perChat int
+	}{
+		{name: "satu worker", workers: 1, chats: 3, perChat: 20},
+		{name: "worker lebih banyak dari chat", workers: 8, chats: 3, perChat: 20},
+		{name: "chat lebih banyak dari worker", workers: 2, chats: 10, perChat: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			seen := make(map[string][]string)
+			running := make(map[string]bool)
+			var overlap string
+
+			handler := func(ctx context.Context, message *events.Message) error {
+				chat := message.Info.Chat.String()
+
+				mu.Lock()
+				if running[chat] {
+					overlap = chat
+				}
+				running[chat] = true
+				seen[chat] = append(seen[chat], message.Info.ID)
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				running[chat] = false
+				mu.Unlock()
+				return nil
+			}
+
+			d := NewDispatcher(context.Background(), DispatcherConfig{
+				Workers:       tt.workers,
+				ChatQueueSize: tt.perChat,
+				MaxPending:    tt.chats * tt.perChat,
+			}, handler, nil)
+
+			// Pesan antar chat diselang-seling agar urutan masuk global tidak sama dengan urutan per chat
+			for i := 0; i < tt.perChat; i++ {
+				for c := 0; c < tt.chats; c++ {
+					if err := d.Submit(testMessage(fmt.Sprint(c), i)); err != nil {
+						t.Fatalf("Submit() error = %v", err)
+					}
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := d.Shutdown(ctx); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			if overlap != "" {
+				t.Errorf("chat %s diproses bersamaan oleh dua worker", overlap)
+			}
+			if len(seen) != tt.chats {
+				t.Fatalf("processed %d chats, want %d", len(seen), tt.chats)
+			}
+			for chat, ids := range seen {
+				if len(ids) != tt.perChat {
+					t.Errorf("chat %s processed %d messages, want %d", chat, len(ids), tt.perChat)
+					continue
+				}
+				for i, id := range ids {
+					if id != fmt.Sprint(i) {
+						t.Errorf("chat %s order = %v, want 0..%d", chat, ids, tt.perChat-1)
+						break
+					}
+				}
+			}
+
+			stats := d.Stats()
+			if want := uint64(tt.chats * tt.perChat); stats.Processed != want || stats.Dropped != 0 {
+				t.Errorf("Stats() processed = %d, dropped = %d, want %d, 0", stats.Processed, stats.Dropped, want)
+			}
+		})
+	}
+}
+
+func TestDispatcherBackpressure(t *testing.T) {
+	const enqueueTimeout = 100 * time.Millisecond
+
+	tests := []struct {
+		name string
+
+		// releaseAfter melepas handler yang tertahan saat Submit sedang menunggu (0 = tidak dilepas)
+		releaseAfter time.Duration
+
+		wantErr error
+	}{
+		{name: "antrean penuh sampai timeout", wantErr: ErrQueueFull},
+		{name: "slot kosong sebelum timeout", releaseAfter: 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{}, 3)
+			release := make(chan struct{})
+			handler := func(ctx context.Context, message *events.Message) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			}
+
+			d := NewDispatcher(context.Background(), DispatcherConfig{
+				Workers:        1,
+				ChatQueueSize:  10,
+				MaxPending:     2,
+				EnqueueTimeout: enqueueTimeout,
+			}, handler, nil)
+
+			// Satu pesan diproses dan satu mengantre memenuhi MaxPending
+			for i := 0; i < 2; i++ {
+				if err := d.Submit(testMessage("1", i)); err != nil {
+					t.Fatalf("Submit(%d) error = %v", i, err)
+				}
+			}
+			<-started
+
+			var releaseOnce sync.Once
+			unblock := func() { releaseOnce.Do(func() { close(release) }) }
+			defer unblock()
+			if tt.releaseAfter > 0 {
+				timer := time.AfterFunc(tt.releaseAfter, unblock)
+				defer timer.Stop()
+			}
+
+			begin := time.Now()
+			err := d.Submit(testMessage("2", 0))
+			elapsed := time.Since(begin)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Submit() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && elapsed < enqueueTimeout {
+				t.Errorf("Submit() returned after %v, want at least %v", elapsed, enqueueTimeout)
+			}
+			if tt.wantErr == nil && elapsed >= enqueueTimeout {
+				t.Errorf("Submit() returned after %v, want before %v", elapsed, enqueueTimeout)
+			}
+
+			unblock()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := d.Shutdown(ctx); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			stats := d.Stats()
+			wantDropped := uint64(0)
+			if tt.wantErr != nil {
+				wantDropped = 1
+			}
+			if stats.Dropped != wantDropped || stats.Processed+stats.Dropped != stats.Submitted {
+				t.Errorf("Stats() = %+v, want %d dropped and every message accounted for", stats, wantDropped)
+			}
+		})
+	}
+}
+
+func TestDispatcherChatQueueSize(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	handler := func(ctx context.Context, message *events.Message) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	}
+
+	d := NewDispatcher(context.Background(), DispatcherConfig{
+		Workers:        2,
+		ChatQueueSize:  2,
+		MaxPending:     100,
+		EnqueueTimeout: time.Second,
+	}, handler, nil)
+
+	// Pesan pertama diproses, dua berikutnya mengisi antrean chat
+	for i := 0; i < 3; i++ {
+		if err := d.Submit(testMessage("1", i)); err != nil {
+			t.Fatalf("Submit(%d) error = %v", i, err)
+		}
+		if i == 0 {
+			<-started
+		}
+	}
+
+	begin := time.Now()
+	if err := d.Submit(testMessage("1", 3)); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit() to full chat error = %v, want %v", err, ErrQueueFull)
+	}
+	if elapsed := time.Since(begin); elapsed >= time.Second {
+		t.Errorf("Submit() to full chat waited %v, want immediate drop", elapsed)
+	}
+
+	// Chat lain tidak ikut tertahan
+	if err := d.Submit(testMessage("2", 0)); err != nil {
+		t.Errorf("Submit() to other chat error = %v", err)
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := d.Submit(testMessage("1", 4)); !errors.Is(err, ErrDispatcherClosed) {
+		t.Errorf("Submit() after Shutdown error = %v, want %v", err, ErrDispatcherClosed)
+	}
+}
@@ -0,0 +1,228 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// eventQueueSize adalah jumlah event yang boleh mengantre per subscriber.
+// Event untuk subscriber yang antreannya penuh dibuang agar event loop whatsmeow
+// tidak ikut tertahan.
+const eventQueueSize = 64
+
+// EventBus meneruskan event whatsmeow (events.GroupInfo, events.CallOffer,
+// events.Receipt, dan lainnya) ke subscriber sesuai tipenya. Setiap subscriber
+// punya antrean dan goroutine sendiri: event diterima berurutan, subscriber yang
+// lambat tidak menahan subscriber lain maupun penerimaan pesan, dan panic atau
+// error satu subscriber tidak mempengaruhi subscriber lain.
+type EventBus struct {
+	errorHandler *ErrorHandler
+
+	// active menentukan apakah owner masih menerima event (nil = semua aktif)
+	active func(owner string) bool
+
+	mu            sync.RWMutex
+	nextID        uint64
+	subscriptions []*eventSubscription
+}
+
+// eventSubscription adalah satu handler yang terdaftar di EventBus beserta antreannya
+type eventSubscription struct {
+	id        uint64
+	owner     string
+	eventType reflect.Type
+	handler   func(evt interface{}) error
+
+	queue chan interface{}
+
+	// done ditutup saat subscription dilepas; event yang masih mengantre dibuang
+	done chan struct{}
+}
+
+// EventScope adalah akses ke EventBus atas nama satu pemilik (biasanya nama plugin)
+// sehingga semua subscription-nya bisa dilepas sekaligus
+type EventScope struct {
+	bus   *EventBus
+	owner string
+}
+
+// EventSubscriber diimplementasikan plugin yang ingin menerima event non-pesan.
+// SubscribeEvents dipanggil saat plugin didaftarkan dan semua subscription
+// dilepas otomatis saat plugin dikeluarkan atau diganti.
+type EventSubscriber interface {
+	SubscribeEvents(scope *EventScope)
+}
+
+// NewEventBus membuat instance baru EventBus
+func NewEventBus(errorHandler *ErrorHandler) *EventBus {
+	return &EventBus{errorHandler: errorHandler}
+}
+
+// Scope mengembalikan akses EventBus atas nama owner
+func (b *EventBus) Scope(owner string) *EventScope {
+	return &EventScope{bus: b, owner: owner}
+}
+
+// Subscribe mendaftarkan handler untuk event bertipe T, misalnya *events.GroupInfo.
+// T berupa interface (misalnya any) menerima semua event yang mengimplementasikannya.
+// Mengembalikan fungsi untuk melepas subscription.
+func Subscribe[T any](scope *EventScope, handler func(evt T) error) (unsubscribe func()) {
+	eventType := reflect.TypeOf((*T)(nil)).Elem()
+	return scope.bus.add(eventType, scope.owner, func(evt interface{}) error {
+		return handler(evt.(T))
+	})
+}
+
+// add mendaftarkan subscription untuk tipe event
+func (b *EventBus) add(eventType reflect.Type, owner string, handler func(evt interface{}) error) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	sub := &eventSubscription{
+		id:        b.nextID,
+		owner:     owner,
+		eventType: eventType,
+		handler:   handler,
+		queue:     make(chan interface{}, eventQueueSize),
+		done:      make(chan struct{}),
+	}
+	b.subscriptions = append(b.subscriptions, sub)
+	go b.run(sub)
+
+	return func() {
+		b.remove(func(s *eventSubscription) bool { return s.id == sub.id })
+	}
+}
+
+// remove melepas semua subscription yang cocok dengan filter
+func (b *EventBus) remove(match func(s *eventSubscription) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	kept := make([]*eventSubscription, 0, len(b.subscriptions))
+	for _, sub := range b.subscriptions {
+		if match(sub) {
+			close(sub.done)
+		} else {
+			kept = append(kept, sub)
+		}
+	}
+	b.subscriptions = kept
+}
+
+// Unsubscribe melepas semua subscription milik scope ini
+func (s *EventScope) Unsubscribe() {
+	s.bus.UnsubscribeOwner(s.owner)
+}
+
+// UnsubscribeOwner melepas semua subscription milik owner
+func (b *EventBus) UnsubscribeOwner(owner string) {
+	b.remove(func(s *eventSubscription) bool { return s.owner == owner })
+}
+
+// Publish memasukkan event ke antrean semua subscriber tipenya sesuai urutan
+// subscribe, lalu langsung kembali tanpa menunggu handler selesai. Subscriber
+// milik plugin yang dinonaktifkan global dilewati.
+func (b *EventBus) Publish(evt interface{}) {
+	if evt == nil {
+		return
+	}
+	eventType := reflect.TypeOf(evt)
+
+	// Subscriber tipe persis didahulukan, lalu subscriber interface
+	b.mu.RLock()
+	var exact, implemented []*eventSubscription
+	for _, sub := range b.subscriptions {
+		switch {
+		case sub.eventType == eventType:
+			exact = append(exact, sub)
+		case sub.eventType.Kind() == reflect.Interface && eventType.Implements(sub.eventType):
+			implemented = append(implemented, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range append(exact, implemented...) {
+		if b.active != nil && !b.active(sub.owner) {
+			continue
+		}
+		select {
+		case sub.queue <- evt:
+		case <-sub.done:
+		default:
+			b.logError(sub, evt, fmt.Errorf("event queue is full, event dropped"))
+		}
+	}
+}
+
+// run menjalankan handler subscriber untuk setiap event di antreannya sampai dilepas
+func (b *EventBus) run(sub *eventSubscription) {
+	for {
+		select {
+		case <-sub.done:
+			return
+		case evt := <-sub.queue:
+			// Subscription yang baru dilepas tidak menerima sisa antreannya
+			select {
+			case <-sub.done:
+				return
+			default:
+			}
+			if err := b.deliver(sub, evt); err != nil {
+				b.logError(sub, evt, err)
+			}
+		}
+	}
+}
+
+// logError mencatat error subscriber
+func (b *EventBus) logError(sub *eventSubscription, evt interface{}, err error) {
+	context := fmt.Sprintf("EventBus[%s].%T", sub.owner, evt)
+	if b.errorHandler != nil {
+		b.errorHandler.LogError(err, context)
+	} else {
+		fmt.Printf("❌ %s: %v\n", context, err)
+	}
+}
+
+// deliver menjalankan satu subscriber dan mengubah panic menjadi error
+func (b *EventBus) deliver(sub *eventSubscription, evt interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in subscriber: %v", r)
+		}
+	}()
+	return sub.handler(evt)
+}
+
+// SetEventBus menghubungkan plugin manager dengan EventBus. Plugin yang
+// mengimplementasikan EventSubscriber akan di-subscribe saat didaftarkan.
+func (pm *PluginManager) SetEventBus(bus *EventBus) {
+	pm.eventBus = bus
+	bus.active = func(owner string) bool {
+		return pm.pluginState == nil || !pm.pluginState.IsDisabledGlobally(owner)
+	}
+}
+
+// EventBus mengembalikan EventBus yang terhubung (bisa nil)
+func (pm *PluginManager) EventBus() *EventBus {
+	return pm.eventBus
+}
+
+// subscribePlugin mendaftarkan subscription event milik plugin
+func (pm *PluginManager) subscribePlugin(plugin Plugin) {
+	subscriber, ok := plugin.(EventSubscriber)
+	if !ok || pm.eventBus == nil {
+		return
+	}
+	subscriber.SubscribeEvents(pm.eventBus.Scope(plugin.GetName()))
+}
+
+// unsubscribePlugin melepas semua subscription event milik plugin
+func (pm *PluginManager) unsubscribePlugin(name string) {
+	if pm.eventBus != nil {
+		pm.eventBus.UnsubscribeOwner(name)
+	}
+}
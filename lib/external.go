@@ -0,0 +1,640 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ErrPluginUnavailable dikembalikan saat plugin eksternal atau wasm sedang tidak bisa dipakai
+var ErrPluginUnavailable = errors.New("plugin is not available")
+
+const (
+	// externalHandshakeTimeout adalah batas waktu plugin eksternal menjawab initialize
+	externalHandshakeTimeout = 10 * time.Second
+
+	// externalCallTimeout adalah batas waktu default satu command plugin eksternal
+	externalCallTimeout = 60 * time.Second
+
+	// externalMaxBackoff adalah jeda maksimum sebelum proses yang crash dijalankan ulang
+	externalMaxBackoff = time.Minute
+
+	// externalStableAfter adalah lama proses harus hidup agar backoff direset
+	externalStableAfter = time.Minute
+)
+
+// ExternalPluginConfig konfigurasi satu plugin eksternal
+type ExternalPluginConfig struct {
+	// Name adalah nama plugin di PluginManager
+	Name string `json:"name"`
+
+	// Command adalah executable yang dijalankan, misalnya "python3"
+	Command string `json:"command"`
+
+	// Args adalah argumen untuk executable
+	Args []string `json:"args"`
+
+	// Dir adalah working directory proses (kosong = direktori bot)
+	Dir string `json:"dir"`
+
+	// Env adalah variabel lingkungan tambahan dalam format KEY=VALUE
+	Env []string `json:"env"`
+}
+
+// LoadExternalPluginConfigs membaca daftar plugin eksternal dari file JSON.
+// File yang tidak ada dianggap tidak ada plugin eksternal.
+func LoadExternalPluginConfigs(path string) ([]ExternalPluginConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read external plugin config: %v", err)
+	}
+
+	var configs []ExternalPluginConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse external plugin config: %v", err)
+	}
+
+	for i, config := range configs {
+		if config.Name == "" || config.Command == "" {
+			return nil, fmt.Errorf("external plugin #%d: name and command are required", i+1)
+		}
+	}
+	return configs, nil
+}
+
+// externalCommand adalah deskripsi command yang dikirim plugin saat initialize
+type externalCommand struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Usage       string   `json:"usage,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+	Timeout     int      `json:"timeout,omitempty"`
+}
+
+// spec mengubah deskripsi command menjadi CommandSpec. Timeout dalam detik;
+// jika kosong dipakai externalCallTimeout.
+func (c externalCommand) spec() CommandSpec {
+	timeout := externalCallTimeout
+	if c.Timeout > 0 {
+		timeout = time.Duration(c.Timeout) * time.Second
+	}
+	return CommandSpec{
+		Name:        c.Name,
+		Aliases:     c.Aliases,
+		Category:    c.Category,
+		Description: c.Description,
+		Usage:       c.Usage,
+		Examples:    c.Examples,
+		Timeout:     timeout,
+	}
+}
+
+// externalManifest adalah hasil initialize dari plugin eksternal
+type externalManifest struct {
+	Description string            `json:"description"`
+	Commands    []externalCommand `json:"commands"`
+}
+
+// externalInvocation adalah parameter method handle yang dikirim ke plugin
+type externalInvocation struct {
+	Token     int64    `json:"token"`
+	Command   string   `json:"command"`
+	Invoked   string   `json:"invoked"`
+	Args      []string `json:"args"`
+	Text      string   `json:"text"`
+	Prefix    string   `json:"prefix"`
+	Sender    string   `json:"sender"`
+	Chat      string   `json:"chat"`
+	IsGroup   bool     `json:"isGroup"`
+	MessageID string   `json:"messageId"`
+	PushName  string   `json:"pushName"`
+}
+
+// externalResult adalah hasil method handle dari plugin
+type externalResult struct {
+	Reply string `json:"reply"`
+}
+
+// externalProcess adalah satu proses plugin eksternal yang sedang berjalan
+type externalProcess struct {
+	cmd       *exec.Cmd
+	stdin     io.Closer
+	conn      *rpcConn
+	exited    chan struct{}
+	startedAt time.Time
+}
+
+// ExternalPlugin menjalankan plugin sebagai proses terpisah yang berbicara
+// JSON-RPC lewat stdin/stdout. Proses yang crash dijalankan ulang dengan backoff
+// tanpa mengganggu sesi WhatsApp.
+type ExternalPlugin struct {
+	config       ExternalPluginConfig
+	manager      *PluginManager
+	errorHandler *ErrorHandler
+
+	mu       sync.RWMutex
+	manifest externalManifest
+	process  *externalProcess
+	lastErr  error
+
+	invocations sync.Map
+	nextToken   atomic.Int64
+
+	restart  chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// Pastikan ExternalPlugin mengimplementasikan interface yang dibutuhkan
+var (
+	_ Plugin        = (*ExternalPlugin)(nil)
+	_ Stopper       = (*ExternalPlugin)(nil)
+	_ HealthChecker = (*ExternalPlugin)(nil)
+	_ Restarter     = (*ExternalPlugin)(nil)
+)
+
+// NewExternalPlugin membuat instance baru ExternalPlugin. Proses belum dijalankan
+// sampai Launch dipanggil.
+func NewExternalPlugin(config ExternalPluginConfig, manager *PluginManager, errorHandler *ErrorHandler) *ExternalPlugin {
+	return &ExternalPlugin{
+		config:       config,
+		manager:      manager,
+		errorHandler: errorHandler,
+		restart:      make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// Launch menjalankan proses plugin, menunggu daftar command-nya, lalu mulai
+// mengawasi proses. Harus dipanggil sebelum plugin didaftarkan. Jika proses gagal
+// dijalankan, error dikembalikan tetapi supervisor tetap mencoba lagi dengan
+// backoff; plugin tetap didaftarkan dan command-nya muncul setelah proses berjalan.
+func (p *ExternalPlugin) Launch(ctx context.Context) error {
+	process, manifest, err := p.spawn(ctx)
+	if err != nil {
+		p.mu.Lock()
+		p.lastErr = fmt.Errorf("%w: %v", ErrPluginUnavailable, err)
+		p.mu.Unlock()
+
+		go p.supervise(nil)
+		return err
+	}
+
+	p.mu.Lock()
+	p.process = process
+	p.manifest = manifest
+	p.mu.Unlock()
+
+	go p.supervise(process)
+	return nil
+}
+
+// spawn menjalankan proses baru dan melakukan handshake initialize
+func (p *ExternalPlugin) spawn(ctx context.Context) (*externalProcess, externalManifest, error) {
+	var manifest externalManifest
+
+	cmd := exec.Command(p.config.Command, p.config.Args...)
+	cmd.Dir = p.config.Dir
+	cmd.Env = append(os.Environ(), p.config.Env...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, manifest, fmt.Errorf("failed to open stdin of %s: %v", p.config.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, manifest, fmt.Errorf("failed to open stdout of %s: %v", p.config.Name, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, manifest, fmt.Errorf("failed to open stderr of %s: %v", p.config.Name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, manifest, fmt.Errorf("failed to start %s: %v", p.config.Name, err)
+	}
+
+	process := &externalProcess{
+		cmd:       cmd,
+		stdin:     stdin,
+		conn:      newRPCConn(stdout, stdin, p.serveHost),
+		exited:    make(chan struct{}),
+		startedAt: time.Now(),
+	}
+	go p.logStderr(stderr)
+
+	// Tunggu stdout tertutup sebelum Wait, sesuai aturan os/exec
+	go func() {
+		<-process.conn.Done()
+		cmd.Process.Kill()
+		cmd.Wait()
+		close(process.exited)
+	}()
+
+	handshakeCtx, cancel := context.WithTimeout(ctx, externalHandshakeTimeout)
+	defer cancel()
+
+	params := map[string]interface{}{
+		"name":            p.config.Name,
+		"prefix":          p.manager.CommandParser().Prefix(),
+		"protocolVersion": 1,
+	}
+	if err := process.conn.Call(handshakeCtx, "initialize", params, &manifest); err != nil {
+		process.kill()
+		return nil, manifest, fmt.Errorf("handshake with %s failed: %v", p.config.Name, err)
+	}
+	if len(manifest.Commands) == 0 {
+		process.kill()
+		return nil, manifest, fmt.Errorf("external plugin %s declared no commands", p.config.Name)
+	}
+
+	return process, manifest, nil
+}
+
+// notifyShutdown meminta proses keluar dengan bersih. Penulisan ke stdin bisa
+// tertahan selamanya jika plugin berhenti membaca, jadi notifikasi dikirim di
+// goroutine terpisah dan ditunggu paling lama sampai ctx berakhir; kill
+// menutup stdin sehingga penulisan yang tertahan ikut selesai.
+func (ep *externalProcess) notifyShutdown(ctx context.Context) {
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		ep.conn.Notify("shutdown", nil)
+	}()
+
+	select {
+	case <-sent:
+	case <-ep.exited:
+	case <-ctx.Done():
+	}
+}
+
+// kill menghentikan proses dan menunggu sampai benar-benar keluar
+func (ep *externalProcess) kill() {
+	ep.stdin.Close()
+	ep.cmd.Process.Kill()
+	<-ep.exited
+}
+
+// logStderr meneruskan output stderr plugin ke console
+func (p *ExternalPlugin) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		fmt.Printf("🔌 [%s] %s\n", p.config.Name, scanner.Text())
+	}
+}
+
+// supervise menunggu proses keluar lalu menjalankannya ulang dengan backoff.
+// process nil berarti proses pertama gagal dijalankan dan langsung dicoba ulang.
+func (p *ExternalPlugin) supervise(process *externalProcess) {
+	defer close(p.stopped)
+
+	backoff := time.Second
+	for {
+		if process != nil {
+			select {
+			case <-process.exited:
+			case <-p.stop:
+				return
+			}
+			if p.isStopping() {
+				return
+			}
+
+			p.mu.Lock()
+			p.process = nil
+			p.lastErr = fmt.Errorf("%w: process exited", ErrPluginUnavailable)
+			p.mu.Unlock()
+		}
+
+		requested := false
+		select {
+		case <-p.restart:
+			requested = true
+		default:
+		}
+
+		if requested || (process != nil && time.Since(process.startedAt) > externalStableAfter) {
+			backoff = time.Second
+		}
+		switch {
+		case requested:
+		case process == nil:
+			p.logError(fmt.Errorf("process failed to start, retrying in %v", backoff), "supervise")
+		default:
+			p.logError(fmt.Errorf("process exited unexpectedly, restarting in %v", backoff), "supervise")
+		}
+
+		for {
+			if !requested {
+				select {
+				case <-time.After(backoff):
+				case <-p.restart:
+				case <-p.stop:
+					return
+				}
+			}
+			requested = false
+			if p.isStopping() {
+				return
+			}
+
+			next, manifest, err := p.spawn(context.Background())
+			if err == nil {
+				if !p.adopt(next, manifest) {
+					// Stop dipanggil selama spawn; proses baru tidak boleh tertinggal
+					next.kill()
+					return
+				}
+				process = next
+				break
+			}
+
+			p.mu.Lock()
+			p.lastErr = err
+			p.mu.Unlock()
+
+			backoff *= 2
+			if backoff > externalMaxBackoff {
+				backoff = externalMaxBackoff
+			}
+			p.logError(fmt.Errorf("%v, retrying in %v", err, backoff), "supervise")
+		}
+	}
+}
+
+// isStopping mengecek apakah Stop sudah dipanggil
+func (p *ExternalPlugin) isStopping() bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// adopt memakai proses baru dan mendaftarkan ulang command jika berubah.
+// Mengembalikan false tanpa memakai proses jika Stop sudah dipanggil; Stop menutup
+// p.stop sebelum mengambil p.mu, jadi proses yang dipasang di sini selalu terlihat Stop.
+func (p *ExternalPlugin) adopt(process *externalProcess, manifest externalManifest) bool {
+	p.mu.Lock()
+	if p.isStopping() {
+		p.mu.Unlock()
+		return false
+	}
+	previous := p.manifest
+	p.process = process
+	p.manifest = manifest
+	p.lastErr = nil
+	p.mu.Unlock()
+
+	if previous.commandsEqual(manifest) {
+		return true
+	}
+
+	err := p.manager.ReplacePlugin(p)
+	if errors.Is(err, ErrPluginNotFound) {
+		// Proses pertama gagal dan plugin belum didaftarkan; RegisterPlugin
+		// akan membaca manifest baru ini
+		return true
+	}
+	if err != nil {
+		// Pakai kembali command lama agar registry tetap konsisten
+		p.mu.Lock()
+		p.manifest.Commands = previous.Commands
+		p.mu.Unlock()
+		p.logError(err, "adopt")
+	}
+	return true
+}
+
+// commandsEqual membandingkan daftar command dua manifest beserta metadatanya
+func (m externalManifest) commandsEqual(other externalManifest) bool {
+	return reflect.DeepEqual(m.Commands, other.Commands)
+}
+
+// logError mencatat error plugin eksternal
+func (p *ExternalPlugin) logError(err error, context string) {
+	context = fmt.Sprintf("ExternalPlugin[%s].%s", p.config.Name, context)
+	if p.errorHandler != nil {
+		p.errorHandler.LogError(err, context)
+	} else {
+		fmt.Printf("❌ %s: %v\n", context, err)
+	}
+}
+
+// GetName mengembalikan nama plugin
+func (p *ExternalPlugin) GetName() string {
+	return p.config.Name
+}
+
+// GetCommands mengembalikan daftar command yang dilaporkan plugin
+func (p *ExternalPlugin) GetCommands() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var commands []string
+	for _, cmd := range p.manifest.Commands {
+		commands = append(commands, cmd.Name)
+	}
+	return commands
+}
+
+// GetCommandSpecs mengembalikan metadata command yang dilaporkan plugin
+func (p *ExternalPlugin) GetCommandSpecs() []CommandSpec {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var specs []CommandSpec
+	for _, cmd := range p.manifest.Commands {
+		specs = append(specs, cmd.spec())
+	}
+	return specs
+}
+
+// GetDescription mengembalikan deskripsi plugin
+func (p *ExternalPlugin) GetDescription() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.manifest.Description != "" {
+		return p.manifest.Description
+	}
+	return fmt.Sprintf("Plugin eksternal (%s)", p.config.Command)
+}
+
+// currentConn mengembalikan koneksi ke proses yang sedang berjalan
+func (p *ExternalPlugin) currentConn() (*rpcConn, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.process == nil {
+		if p.lastErr != nil {
+			return nil, p.lastErr
+		}
+		return nil, ErrPluginUnavailable
+	}
+	return p.process.conn, nil
+}
+
+// Handle meneruskan command ke proses plugin lewat method handle
+func (p *ExternalPlugin) Handle(ctx *Context) error {
+	conn, err := p.currentConn()
+	if err != nil {
+		ctx.Replyf("⚠️ Plugin *%s* sedang tidak tersedia, coba lagi nanti.", p.config.Name)
+		return err
+	}
+
+	token := p.nextToken.Add(1)
+	p.invocations.Store(token, ctx)
+	defer p.invocations.Delete(token)
+
+	invocation := externalInvocation{
+		Token:     token,
+		Command:   ctx.Command.Name,
+		Invoked:   ctx.Invoked,
+		Args:      ctx.Args,
+		Text:      ctx.RawText,
+		Prefix:    ctx.Prefix,
+		Sender:    ctx.Sender.String(),
+		Chat:      ctx.Chat.String(),
+		IsGroup:   ctx.IsGroup,
+		MessageID: ctx.Event.Info.ID,
+		PushName:  ctx.Event.Info.PushName,
+	}
+
+	var result externalResult
+	// ctx.Ctx sudah dibatasi timeout command dari spec
+	if err := conn.Call(ctx.Ctx, "handle", invocation, &result); err != nil {
+		return fmt.Errorf("external plugin %s failed: %v", p.config.Name, err)
+	}
+
+	if result.Reply != "" {
+		return ctx.Reply(result.Reply)
+	}
+	return nil
+}
+
+// serveHost menangani request dari plugin: reply, react, send dan log
+func (p *ExternalPlugin) serveHost(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Token   int64  `json:"token"`
+		Text    string `json:"text"`
+		Emoji   string `json:"emoji"`
+		Chat    string `json:"chat"`
+		Message string `json:"message"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+	}
+
+	switch method {
+	case "reply", "react":
+		value, ok := p.invocations.Load(req.Token)
+		if !ok {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: "unknown or finished invocation token"}
+		}
+		invocation := value.(*Context)
+		if method == "reply" {
+			return true, invocation.Reply(req.Text)
+		}
+		return true, invocation.React(req.Emoji)
+	case "send":
+		chat, err := types.ParseJID(req.Chat)
+		if err != nil {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid chat: %v", err)}
+		}
+		_, err = NewMessage(p.manager.client, chat).Text(req.Text).Send(ctx)
+		return true, err
+	case "log":
+		if p.errorHandler != nil {
+			p.errorHandler.LogInfo(req.Message, fmt.Sprintf("ExternalPlugin[%s]", p.config.Name))
+		}
+		return true, nil
+	default:
+		return nil, &RPCError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", method)}
+	}
+}
+
+// Restart mematikan proses plugin; supervisor segera menjalankannya kembali
+// dan mendaftarkan ulang command jika berubah
+func (p *ExternalPlugin) Restart(ctx context.Context) error {
+	p.mu.RLock()
+	process := p.process
+	p.mu.RUnlock()
+
+	select {
+	case p.restart <- struct{}{}:
+	default:
+	}
+
+	if process != nil {
+		process.notifyShutdown(ctx)
+		process.kill()
+	}
+	return nil
+}
+
+// Stop menghentikan supervisor dan proses plugin
+func (p *ExternalPlugin) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+
+	p.mu.Lock()
+	process := p.process
+	p.process = nil
+	p.lastErr = ErrPluginUnavailable
+	p.mu.Unlock()
+
+	if process != nil {
+		// Beri kesempatan plugin keluar dengan bersih sebelum dipaksa
+		process.notifyShutdown(ctx)
+		process.stdin.Close()
+		select {
+		case <-process.exited:
+		case <-ctx.Done():
+			process.kill()
+		}
+	}
+
+	select {
+	case <-p.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HealthCheck mengecek apakah proses plugin berjalan dan merespons ping
+func (p *ExternalPlugin) HealthCheck(ctx context.Context) error {
+	conn, err := p.currentConn()
+	if err != nil {
+		return err
+	}
+
+	err = conn.Call(ctx, "ping", nil, nil)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == rpcMethodNotFound {
+		// Plugin tidak wajib mengimplementasikan ping
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package lib
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SettingFeedback adalah kunci ChatSettings untuk menyalakan atau mematikan
+// reaksi status dan status mengetik saat command berjalan di satu chat
+const SettingFeedback = "feedback"
+
+// feedbackTypingInterval adalah jarak pengiriman ulang status mengetik.
+// WhatsApp menghapus status mengetik sendiri setelah sekitar 25 detik.
+const feedbackTypingInterval = 10 * time.Second
+
+// feedbackDeadline adalah batas waktu mengirim reaksi akhir setelah command selesai
+const feedbackDeadline = 10 * time.Second
+
+// FeedbackConfig mengatur tanda status command untuk user: reaksi di pesan
+// command dan status "sedang mengetik" selama plugin berjalan
+type FeedbackConfig struct {
+	// Enabled menyalakan tanda status. Setiap chat bisa mematikannya lewat SettingFeedback.
+	Enabled bool
+
+	// Running adalah reaksi saat command mulai berjalan (kosong = tanpa reaksi)
+	Running string
+
+	// Success adalah reaksi saat command selesai tanpa error (kosong = tanpa reaksi)
+	Success string
+
+	// Failure adalah reaksi saat command gagal, panic atau timeout (kosong = tanpa reaksi)
+	Failure string
+
+	// Typing mengirim status mengetik selama command berjalan
+	Typing bool
+}
+
+// DefaultFeedbackConfig mengembalikan konfigurasi default tanda status command
+func DefaultFeedbackConfig() FeedbackConfig {
+	return FeedbackConfig{
+		Enabled: true,
+		Running: "⏳",
+		Success: "✅",
+		Failure: "❌",
+		Typing:  true,
+	}
+}
+
+// SetFeedback mengatur reaksi status dan status mengetik saat command berjalan
+func (pm *PluginManager) SetFeedback(config FeedbackConfig) {
+	pm.feedback = config
+}
+
+// Feedback mengembalikan konfigurasi tanda status command yang berlaku
+func (pm *PluginManager) Feedback() FeedbackConfig {
+	return pm.feedback
+}
+
+// commandFeedback adalah tanda status untuk satu command. Tanda baru dikirim
+// saat feedbackGuard dilewati, sehingga command yang ditolak guard (user diblokir,
+// rate limit, izin, cooldown) tidak diberi reaksi.
+type commandFeedback struct {
+	config FeedbackConfig
+
+	// status adalah salinan Context untuk reaksi akhir, dibuat sebelum handler
+	// berjalan agar tidak bersinggungan dengan ctx yang masih dipakai handler
+	status Context
+
+	mu       sync.Mutex
+	started  bool
+	finished bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// newFeedback menyiapkan tanda status untuk command, nil jika dimatikan untuk chat ini
+func (pm *PluginManager) newFeedback(ctx *Context) *commandFeedback {
+	if !pm.feedback.Enabled || pm.client == nil {
+		return nil
+	}
+	if pm.chatSettings != nil && !pm.chatSettings.Bool(ctx.Chat, SettingFeedback, true) {
+		return nil
+	}
+	return &commandFeedback{config: pm.feedback, status: *ctx}
+}
+
+// feedbackGuard menandai command mulai berjalan setelah semua pengecekan lolos
+func (pm *PluginManager) feedbackGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		ctx.feedback.start(ctx)
+		return next(ctx)
+	}
+}
+
+// start mengirim reaksi Running dan mulai mengirim status mengetik.
+// Kegagalan mengirim tanda status tidak menggagalkan command.
+func (f *commandFeedback) start(ctx *Context) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.started || f.finished {
+		return
+	}
+	f.started = true
+
+	if f.config.Running != "" {
+		ctx.React(f.config.Running)
+	}
+	if f.config.Typing {
+		f.stop = make(chan struct{})
+		f.stopped = make(chan struct{})
+		go f.keepTyping(ctx.Chat)
+	}
+}
+
+// keepTyping mengirim status mengetik berulang sampai command selesai
+func (f *commandFeedback) keepTyping(chat types.JID) {
+	defer close(f.stopped)
+
+	client := f.status.Client
+	ticker := time.NewTicker(feedbackTypingInterval)
+	defer ticker.Stop()
+
+	for {
+		client.SendChatPresence(chat, types.ChatPresenceComposing, types.ChatPresenceMediaText)
+		select {
+		case <-f.stop:
+			client.SendChatPresence(chat, types.ChatPresencePaused, types.ChatPresenceMediaText)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// finish menghentikan status mengetik dan mengganti reaksi dengan Success atau
+// Failure. parent adalah context dispatch, bukan context command yang mungkin sudah habis.
+func (f *commandFeedback) finish(parent context.Context, err error) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finished = true
+	if !f.started {
+		return
+	}
+
+	if f.stop != nil {
+		close(f.stop)
+		<-f.stopped
+	}
+
+	emoji := f.config.Success
+	if err != nil {
+		emoji = f.config.Failure
+	}
+	if emoji == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), feedbackDeadline)
+	defer cancel()
+	f.status.Ctx = ctx
+	f.status.React(emoji)
+}
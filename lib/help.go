@@ -0,0 +1,54 @@
+package lib
+
+// HelpCategory adalah sekelompok command dengan kategori yang sama di menu bantuan
+type HelpCategory struct {
+	Name     string
+	Commands []*Command
+}
+
+// CanRun mengecek apakah pengirim pesan di ctx boleh menjalankan command:
+// plugin-nya aktif di chat ini dan role pengirim mencukupi
+func (pm *PluginManager) CanRun(ctx *Context, cmd *Command) bool {
+	if !pm.IsPluginEnabled(cmd.Plugin.GetName(), ctx.Chat) {
+		return false
+	}
+	return ctx.CanUse(cmd.Role)
+}
+
+// AvailableCommands mengembalikan command yang boleh dijalankan pengirim pesan di ctx
+// sesuai urutan registrasi
+func (pm *PluginManager) AvailableCommands(ctx *Context) []*Command {
+	var commands []*Command
+	for _, cmd := range pm.registry.Commands() {
+		if pm.CanRun(ctx, cmd) {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+// GroupByCategory mengelompokkan command per kategori. Urutan kategori mengikuti
+// kemunculan pertamanya, DefaultCategory selalu diletakkan paling akhir.
+func GroupByCategory(commands []*Command) []HelpCategory {
+	var categories []HelpCategory
+	index := make(map[string]int)
+
+	for _, cmd := range commands {
+		name := cmd.CategoryName()
+		i, ok := index[name]
+		if !ok {
+			i = len(categories)
+			index[name] = i
+			categories = append(categories, HelpCategory{Name: name})
+		}
+		categories[i].Commands = append(categories[i].Commands, cmd)
+	}
+
+	if i, ok := index[DefaultCategory]; ok && i != len(categories)-1 {
+		other := categories[i]
+		categories = append(categories[:i], categories[i+1:]...)
+		categories = append(categories, other)
+	}
+
+	return categories
+}
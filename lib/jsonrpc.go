@@ -0,0 +1,226 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrRPCClosed dikembalikan saat koneksi JSON-RPC sudah tertutup
+var ErrRPCClosed = errors.New("json-rpc connection closed")
+
+// rpcMessage adalah satu pesan JSON-RPC 2.0 (request, notifikasi atau response)
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *RPCError        `json:"error,omitempty"`
+}
+
+// RPCError adalah objek error JSON-RPC
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error mengimplementasikan interface error
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+// Kode error standar JSON-RPC
+const (
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// rpcHandler menangani request dari sisi lain. Hasil dikirim sebagai result,
+// error dikirim sebagai objek error.
+type rpcHandler func(ctx context.Context, method string, params json.RawMessage) (interface{}, error)
+
+// rpcConn adalah koneksi JSON-RPC dua arah di atas stream yang dipisahkan baris baru
+type rpcConn struct {
+	writer  io.Writer
+	handler rpcHandler
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *rpcMessage
+	closed  bool
+	done    chan struct{}
+}
+
+// newRPCConn membuat koneksi JSON-RPC dan mulai membaca pesan dari reader
+func newRPCConn(reader io.Reader, writer io.Writer, handler rpcHandler) *rpcConn {
+	conn := &rpcConn{
+		writer:  writer,
+		handler: handler,
+		pending: make(map[int64]chan *rpcMessage),
+		done:    make(chan struct{}),
+	}
+	go conn.readLoop(reader)
+	return conn
+}
+
+// Done ditutup saat koneksi berakhir
+func (c *rpcConn) Done() <-chan struct{} {
+	return c.done
+}
+
+// readLoop membaca pesan baris per baris sampai stream ditutup
+func (c *rpcConn) readLoop(reader io.Reader) {
+	defer c.close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			// Baris yang bukan JSON (misalnya print debug) diabaikan
+			continue
+		}
+
+		switch {
+		case msg.Method != "":
+			go c.serve(&msg)
+		case msg.ID != nil:
+			c.deliver(&msg)
+		}
+	}
+}
+
+// serve menjalankan handler untuk request atau notifikasi dari sisi lain
+func (c *rpcConn) serve(msg *rpcMessage) {
+	result, err := c.handler(context.Background(), msg.Method, msg.Params)
+	if msg.ID == nil {
+		return
+	}
+
+	response := &rpcMessage{JSONRPC: "2.0", ID: msg.ID}
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &RPCError{Code: rpcInternalError, Message: err.Error()}
+		}
+		response.Error = rpcErr
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			response.Error = &RPCError{Code: rpcInternalError, Message: err.Error()}
+		} else {
+			response.Result = data
+		}
+	}
+	c.write(response)
+}
+
+// deliver meneruskan response ke pemanggil yang menunggu
+func (c *rpcConn) deliver(msg *rpcMessage) {
+	var id int64
+	if err := json.Unmarshal(*msg.ID, &id); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	ch, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+
+	if ok {
+		ch <- msg
+	}
+}
+
+// write mengirim satu pesan diakhiri baris baru
+func (c *rpcConn) write(msg *rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err = c.writer.Write(append(data, '\n'))
+	return err
+}
+
+// Call mengirim request dan menunggu response. Result di-decode ke result jika tidak nil.
+func (c *rpcConn) Call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrRPCClosed
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *rpcMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	rawID := json.RawMessage(fmt.Sprintf("%d", id))
+	if err := c.write(&rpcMessage{JSONRPC: "2.0", ID: &rawID, Method: method, Params: data}); err != nil {
+		return fmt.Errorf("failed to send %s: %v", method, err)
+	}
+
+	select {
+	case response := <-ch:
+		if response == nil {
+			return ErrRPCClosed
+		}
+		if response.Error != nil {
+			return response.Error
+		}
+		if result != nil && len(response.Result) > 0 {
+			return json.Unmarshal(response.Result, result)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify mengirim notifikasi tanpa menunggu response
+func (c *rpcConn) Notify(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&rpcMessage{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+// close menandai koneksi tertutup dan membatalkan semua call yang menunggu
+func (c *rpcConn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	close(c.done)
+}
@@ -0,0 +1,181 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Initializer diimplementasikan plugin yang perlu membuka resource sebelum dipakai.
+// Plugin yang gagal Init dikeluarkan dari registry agar tidak menerima command.
+type Initializer interface {
+	Init(ctx context.Context) error
+}
+
+// Starter diimplementasikan plugin yang menjalankan goroutine latar belakang
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper diimplementasikan plugin yang perlu membersihkan resource saat bot berhenti
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// HealthChecker diimplementasikan plugin yang bisa melaporkan kondisinya
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Restarter diimplementasikan plugin yang bisa dijalankan ulang tanpa restart bot,
+// misalnya plugin eksternal
+type Restarter interface {
+	Restart(ctx context.Context) error
+}
+
+// DefaultLifecycleTimeout adalah batas waktu default setiap tahap lifecycle per plugin
+const DefaultLifecycleTimeout = 10 * time.Second
+
+// PluginError menyimpan error lifecycle dari satu plugin
+type PluginError struct {
+	Plugin string
+	Phase  string
+	Err    error
+}
+
+// Error mengimplementasikan interface error
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("plugin %s: %s failed: %v", e.Plugin, e.Phase, e.Err)
+}
+
+// Unwrap mengembalikan error asli
+func (e *PluginError) Unwrap() error {
+	return e.Err
+}
+
+// runPhase menjalankan satu tahap lifecycle dengan timeout dan recovery panic.
+// Jika plugin mengabaikan context, runPhase tetap kembali setelah timeout.
+func runPhase(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetLifecycleTimeout mengatur batas waktu setiap tahap lifecycle per plugin
+func (pm *PluginManager) SetLifecycleTimeout(timeout time.Duration) {
+	pm.lifecycleTimeout = timeout
+}
+
+// InitPlugins memanggil Init pada semua plugin sesuai urutan registrasi.
+// Plugin yang gagal dikeluarkan dari registry; semua error digabung.
+func (pm *PluginManager) InitPlugins(ctx context.Context) error {
+	var errs []error
+	for _, plugin := range pm.registry.Plugins() {
+		initializer, ok := plugin.(Initializer)
+		if !ok {
+			continue
+		}
+
+		if err := runPhase(ctx, pm.lifecycleTimeout, initializer.Init); err != nil {
+			pm.UnregisterPlugin(plugin.GetName())
+			errs = append(errs, &PluginError{Plugin: plugin.GetName(), Phase: "init", Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StartPlugins memanggil Start pada semua plugin sesuai urutan registrasi
+func (pm *PluginManager) StartPlugins(ctx context.Context) error {
+	var errs []error
+	for _, plugin := range pm.registry.Plugins() {
+		starter, ok := plugin.(Starter)
+		if !ok {
+			continue
+		}
+
+		if err := runPhase(ctx, pm.lifecycleTimeout, starter.Start); err != nil {
+			errs = append(errs, &PluginError{Plugin: plugin.GetName(), Phase: "start", Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StopPlugins memanggil Stop pada semua plugin dengan urutan terbalik
+// agar plugin yang didaftarkan terakhir dihentikan lebih dulu
+func (pm *PluginManager) StopPlugins(ctx context.Context) error {
+	plugins := pm.registry.Plugins()
+
+	var errs []error
+	for i := len(plugins) - 1; i >= 0; i-- {
+		stopper, ok := plugins[i].(Stopper)
+		if !ok {
+			continue
+		}
+
+		if err := runPhase(ctx, pm.lifecycleTimeout, stopper.Stop); err != nil {
+			errs = append(errs, &PluginError{Plugin: plugins[i].GetName(), Phase: "stop", Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RestartPlugin menjalankan ulang plugin yang mengimplementasikan Restarter
+func (pm *PluginManager) RestartPlugin(ctx context.Context, name string) error {
+	plugin, ok := pm.registry.Plugin(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+	}
+
+	restarter, ok := plugin.(Restarter)
+	if !ok {
+		return fmt.Errorf("plugin %s does not support restart", name)
+	}
+
+	if err := runPhase(ctx, pm.lifecycleTimeout, restarter.Restart); err != nil {
+		return &PluginError{Plugin: name, Phase: "restart", Err: err}
+	}
+	return nil
+}
+
+// HealthCheck memeriksa kondisi semua plugin yang mengimplementasikan HealthChecker.
+// Nilai nil pada map berarti plugin sehat.
+func (pm *PluginManager) HealthCheck(ctx context.Context) map[string]error {
+	results := make(map[string]error)
+	for _, plugin := range pm.registry.Plugins() {
+		checker, ok := healthChecker(plugin)
+		if !ok {
+			continue
+		}
+		results[plugin.GetName()] = runPhase(ctx, pm.lifecycleTimeout, checker.HealthCheck)
+	}
+	return results
+}
+
+// healthChecker mengembalikan HealthChecker milik plugin. Plugin lama yang dibungkus
+// adapter hanya dihitung jika plugin aslinya mengimplementasikan HealthChecker.
+func healthChecker(plugin Plugin) (HealthChecker, bool) {
+	if adapter, ok := plugin.(*legacyPluginAdapter); ok {
+		if _, ok := adapter.LegacyPlugin.(HealthChecker); !ok {
+			return nil, false
+		}
+	}
+	checker, ok := plugin.(HealthChecker)
+	return checker, ok
+}
@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrStopPropagation dikembalikan handler listener untuk menghentikan pemrosesan pesan:
+// listener berikutnya dan command di pesan yang sama tidak dijalankan
+var ErrStopPropagation = errors.New("stop propagation")
+
+// TriggerKind menentukan pesan seperti apa yang memicu listener
+type TriggerKind int
+
+const (
+	// TriggerAll memicu listener untuk setiap pesan di chat, termasuk command dan
+	// pesan tanpa teks, misalnya untuk moderasi, XP atau logging
+	TriggerAll TriggerKind = iota
+
+	// TriggerKeyword memicu listener jika teks pesan mengandung salah satu Keywords
+	// sebagai kata utuh (tidak membedakan huruf besar/kecil)
+	TriggerKeyword
+
+	// TriggerRegex memicu listener jika teks pesan cocok dengan Pattern
+	TriggerRegex
+
+	// TriggerMention memicu listener jika bot di-mention atau pesan bot dibalas
+	TriggerMention
+)
+
+// Listener adalah handler pasif yang dipicu pesan biasa, bukan command berprefix.
+// Listener selain TriggerAll tidak dipicu oleh pesan command.
+type Listener struct {
+	// Name adalah nama listener untuk log error
+	Name string
+
+	// Trigger adalah jenis pemicu listener
+	Trigger TriggerKind
+
+	// Keywords adalah kata pemicu untuk TriggerKeyword
+	Keywords []string
+
+	// Pattern adalah regex pemicu untuk TriggerRegex
+	Pattern *regexp.Regexp
+
+	// Priority menentukan urutan; listener dengan nilai lebih besar dijalankan lebih dulu
+	Priority int
+
+	// Handler dijalankan saat listener terpicu. Context.Command bernilai nil dan
+	// Context.Match berisi keyword atau hasil regex yang cocok.
+	Handler Handler
+}
+
+// ListenerProvider diimplementasikan plugin yang ingin menerima pesan tanpa command
+type ListenerProvider interface {
+	GetListeners() []Listener
+}
+
+// boundListener adalah listener terdaftar beserta plugin pemiliknya
+type boundListener struct {
+	Listener
+	plugin Plugin
+	order  int
+}
+
+// listenersOf mengambil dan memvalidasi listener plugin
+func listenersOf(plugin Plugin) ([]Listener, error) {
+	provider, ok := plugin.(ListenerProvider)
+	if !ok {
+		return nil, nil
+	}
+
+	listeners := provider.GetListeners()
+	for _, listener := range listeners {
+		if listener.Handler == nil {
+			return nil, fmt.Errorf("plugin %s: listener %s has no handler", plugin.GetName(), listener.Name)
+		}
+		switch {
+		case listener.Trigger == TriggerKeyword && len(listener.Keywords) == 0:
+			return nil, fmt.Errorf("plugin %s: keyword listener %s has no keywords", plugin.GetName(), listener.Name)
+		case listener.Trigger == TriggerRegex && listener.Pattern == nil:
+			return nil, fmt.Errorf("plugin %s: regex listener %s has no pattern", plugin.GetName(), listener.Name)
+		}
+	}
+	return listeners, nil
+}
+
+// sortListeners mengurutkan listener berdasarkan prioritas lalu urutan registrasi
+func sortListeners(listeners []*boundListener) {
+	sort.SliceStable(listeners, func(i, j int) bool {
+		if listeners[i].Priority != listeners[j].Priority {
+			return listeners[i].Priority > listeners[j].Priority
+		}
+		return listeners[i].order < listeners[j].order
+	})
+}
+
+// match mengecek apakah listener terpicu oleh pesan di ctx.
+// Mengembalikan keyword atau hasil regex yang cocok.
+func (l *boundListener) match(ctx *Context, isCommand bool, botMentioned bool) ([]string, bool) {
+	if l.Trigger == TriggerAll {
+		return nil, true
+	}
+	if isCommand {
+		return nil, false
+	}
+
+	switch l.Trigger {
+	case TriggerKeyword:
+		for _, keyword := range l.Keywords {
+			if containsWord(ctx.RawText, keyword) {
+				return []string{keyword}, true
+			}
+		}
+	case TriggerRegex:
+		if ctx.RawText == "" {
+			return nil, false
+		}
+		if match := l.Pattern.FindStringSubmatch(ctx.RawText); match != nil {
+			return match, true
+		}
+	case TriggerMention:
+		return nil, botMentioned
+	}
+	return nil, false
+}
+
+// containsWord mengecek apakah text mengandung word sebagai kata utuh tanpa
+// membedakan huruf besar/kecil
+func containsWord(text, word string) bool {
+	text, word = strings.ToLower(text), strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return false
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isWordRune mengecek apakah r bagian dari kata
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// isBotMentioned mengecek apakah bot di-mention atau pesan bot dibalas di ctx
+func (pm *PluginManager) isBotMentioned(ctx *Context) bool {
+	if pm.client == nil || pm.client.Store.ID == nil {
+		return false
+	}
+
+	own := map[string]bool{pm.client.Store.ID.User: true}
+	if !pm.client.Store.LID.IsEmpty() {
+		own[pm.client.Store.LID.User] = true
+	}
+
+	for _, jid := range mentionedJIDs(ctx.Event.Message) {
+		if own[jid.User] {
+			return true
+		}
+	}
+
+	return ctx.Quoted != nil && own[ctx.Quoted.Sender.User]
+}
+
+// runListeners menjalankan listener yang terpicu sesuai prioritas. Mengembalikan
+// stopped=true jika ada listener yang mengembalikan ErrStopPropagation. Error dan
+// panic satu listener tidak menghentikan listener lain.
+func (pm *PluginManager) runListeners(base *Context, isCommand bool) (stopped bool, err error) {
+	listeners := pm.registry.listenerList()
+	if len(listeners) == 0 {
+		return false, nil
+	}
+
+	banned := pm.permissions != nil && pm.permissions.IsBanned(base.Ctx, base.Event.Info.MessageSource)
+	mentioned := pm.isBotMentioned(base)
+
+	var errs []error
+	for _, listener := range listeners {
+		// User yang diblokir hanya terlihat oleh listener TriggerAll, misalnya moderasi
+		if banned && listener.Trigger != TriggerAll {
+			continue
+		}
+		if !pm.IsPluginEnabled(listener.plugin.GetName(), base.Chat) {
+			continue
+		}
+
+		match, ok := listener.match(base, isCommand, mentioned)
+		if !ok {
+			continue
+		}
+
+		ctx := *base
+		ctx.Match = match
+		if err := runListener(&ctx, listener); err != nil {
+			if errors.Is(err, ErrStopPropagation) {
+				return true, errors.Join(errs...)
+			}
+			errs = append(errs, fmt.Errorf("listener %s of plugin %s: %w", listener.Name, listener.plugin.GetName(), err))
+		}
+	}
+	return false, errors.Join(errs...)
+}
+
+// runListener menjalankan satu listener dan mengubah panic menjadi error
+func runListener(ctx *Context, listener *boundListener) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return listener.Handler(ctx)
+}
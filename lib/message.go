@@ -0,0 +1,428 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrEmptyMessage dikembalikan saat pesan dikirim tanpa isi
+var ErrEmptyMessage = errors.New("message has no content")
+
+// Jenis isi pesan di MessageBuilder. Satu pesan hanya bisa berisi satu jenis.
+const (
+	contentText     = "text"
+	contentMedia    = "media"
+	contentLocation = "location"
+	contentContact  = "contact"
+	contentForward  = "forward"
+	contentPoll     = "poll"
+)
+
+// LinkPreview adalah pratinjau link yang ditampilkan di bawah pesan teks
+type LinkPreview struct {
+	// URL adalah link yang dipratinjau. Ditambahkan ke akhir teks jika belum ada.
+	URL string
+
+	// Title adalah judul halaman
+	Title string
+
+	// Description adalah deskripsi singkat halaman
+	Description string
+
+	// Thumbnail adalah gambar kecil JPEG (opsional)
+	Thumbnail []byte
+}
+
+// MessageBuilder membangun pesan keluar secara berantai, misalnya
+// ctx.NewReply().Text("Halo @628123").Mention(jid).Send(ctx.Ctx).
+// Error dari langkah mana pun dikembalikan saat Build atau Send.
+type MessageBuilder struct {
+	client *whatsmeow.Client
+	chat   types.JID
+
+	content  string
+	text     string
+	link     *LinkPreview
+	media    *pendingMedia
+	location *waE2E.LocationMessage
+	contacts []*waE2E.ContactMessage
+	forward  *waE2E.Message
+	poll     *waE2E.Message
+
+	mentions []string
+	quote    *waE2E.ContextInfo
+	err      error
+}
+
+// pendingMedia adalah media yang baru di-upload saat pesan dibangun
+type pendingMedia struct {
+	kind    MediaKind
+	data    []byte
+	options MediaOptions
+}
+
+// NewMessage membuat MessageBuilder untuk mengirim pesan ke chat
+func NewMessage(client *whatsmeow.Client, chat types.JID) *MessageBuilder {
+	return &MessageBuilder{client: client, chat: chat}
+}
+
+// Text mengisi pesan dengan teks. Tulis @nomor di teks lalu panggil Mention
+// agar nomor tersebut benar-benar di-mention.
+func (b *MessageBuilder) Text(text string) *MessageBuilder {
+	if b.setContent(contentText) {
+		b.text = text
+	}
+	return b
+}
+
+// Textf seperti Text dengan format ala fmt.Sprintf
+func (b *MessageBuilder) Textf(format string, args ...interface{}) *MessageBuilder {
+	return b.Text(fmt.Sprintf(format, args...))
+}
+
+// Mention menambahkan JID yang di-mention di teks atau caption
+func (b *MessageBuilder) Mention(jids ...types.JID) *MessageBuilder {
+	for _, jid := range jids {
+		raw := jid.ToNonAD().String()
+		if !containsString(b.mentions, raw) {
+			b.mentions = append(b.mentions, raw)
+		}
+	}
+	return b
+}
+
+// LinkPreview menambahkan pratinjau link ke pesan teks
+func (b *MessageBuilder) LinkPreview(preview LinkPreview) *MessageBuilder {
+	if b.setContent(contentText) {
+		b.link = &preview
+	}
+	return b
+}
+
+// Media mengisi pesan dengan media. Media baru di-upload saat Build atau Send.
+func (b *MessageBuilder) Media(kind MediaKind, data []byte, options MediaOptions) *MessageBuilder {
+	if b.setContent(contentMedia) {
+		b.media = &pendingMedia{kind: kind, data: data, options: options}
+	}
+	return b
+}
+
+// Image mengisi pesan dengan gambar dan caption
+func (b *MessageBuilder) Image(data []byte, caption string) *MessageBuilder {
+	return b.Media(ImageMedia, data, MediaOptions{Caption: caption})
+}
+
+// Video mengisi pesan dengan video dan caption
+func (b *MessageBuilder) Video(data []byte, caption string) *MessageBuilder {
+	return b.Media(VideoMedia, data, MediaOptions{Caption: caption})
+}
+
+// Audio mengisi pesan dengan audio, voiceNote untuk voice note
+func (b *MessageBuilder) Audio(data []byte, voiceNote bool) *MessageBuilder {
+	return b.Media(AudioMedia, data, MediaOptions{VoiceNote: voiceNote})
+}
+
+// Document mengisi pesan dengan dokumen, nama file dan caption
+func (b *MessageBuilder) Document(data []byte, fileName string, caption string) *MessageBuilder {
+	return b.Media(DocumentMedia, data, MediaOptions{FileName: fileName, Caption: caption})
+}
+
+// Sticker mengisi pesan dengan stiker WebP
+func (b *MessageBuilder) Sticker(data []byte, animated bool) *MessageBuilder {
+	return b.Media(StickerMedia, data, MediaOptions{Animated: animated})
+}
+
+// Location mengisi pesan dengan lokasi. Name dan address boleh kosong.
+func (b *MessageBuilder) Location(latitude, longitude float64, name, address string) *MessageBuilder {
+	if b.setContent(contentLocation) {
+		b.location = &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(latitude),
+			DegreesLongitude: proto.Float64(longitude),
+		}
+		if name != "" {
+			b.location.Name = proto.String(name)
+		}
+		if address != "" {
+			b.location.Address = proto.String(address)
+		}
+	}
+	return b
+}
+
+// Contact menambahkan kontak dengan nama dan nomor telepon. Beberapa kontak
+// dikirim sebagai satu pesan daftar kontak.
+func (b *MessageBuilder) Contact(name, phone string) *MessageBuilder {
+	return b.ContactVCard(name, VCard(name, phone))
+}
+
+// ContactVCard menambahkan kontak dari vCard yang sudah jadi
+func (b *MessageBuilder) ContactVCard(name, vcard string) *MessageBuilder {
+	if b.setContent(contentContact) {
+		b.contacts = append(b.contacts, &waE2E.ContactMessage{
+			DisplayName: proto.String(name),
+			Vcard:       proto.String(vcard),
+		})
+	}
+	return b
+}
+
+// Forward mengisi pesan dengan salinan pesan lain (misalnya ctx.Quoted.Message)
+// dan menandainya sebagai pesan yang diteruskan. Media tidak perlu di-upload ulang.
+func (b *MessageBuilder) Forward(message *waE2E.Message) *MessageBuilder {
+	if message == nil {
+		b.err = errors.New("cannot forward empty message")
+		return b
+	}
+	if b.setContent(contentForward) {
+		b.forward = cleanMessage(message)
+		markForwarded(b.forward)
+	}
+	return b
+}
+
+// Poll mengisi pesan dengan polling native WhatsApp. selectable adalah jumlah
+// pilihan maksimal per user (0 berarti bebas). Suara masuk sebagai PollUpdateMessage
+// yang bisa didekripsi dengan Client.DecryptPollVote.
+func (b *MessageBuilder) Poll(question string, options []string, selectable int) *MessageBuilder {
+	if b.setContent(contentPoll) {
+		b.poll = b.client.BuildPollCreation(question, options, selectable)
+	}
+	return b
+}
+
+// Quote membalas (mengutip) pesan masuk. Pesan dikutip sesuai jenis aslinya,
+// misalnya gambar tetap tampil sebagai gambar di kutipan.
+func (b *MessageBuilder) Quote(message *events.Message) *MessageBuilder {
+	if message == nil {
+		return b
+	}
+	return b.QuoteMessage(message.Info.ID, message.Info.Sender, message.Message)
+}
+
+// QuoteMessage membalas pesan berdasarkan ID, pengirim dan isinya,
+// misalnya untuk mengutip ctx.Quoted
+func (b *MessageBuilder) QuoteMessage(id types.MessageID, sender types.JID, message *waE2E.Message) *MessageBuilder {
+	b.quote = &waE2E.ContextInfo{
+		StanzaID:      proto.String(id),
+		Participant:   proto.String(sender.ToNonAD().String()),
+		QuotedMessage: cleanMessage(message),
+	}
+	return b
+}
+
+// Build meng-upload media (jika ada) lalu membuat pesan protobuf tanpa mengirimnya
+func (b *MessageBuilder) Build(ctx context.Context) (*waE2E.Message, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var message *waE2E.Message
+	switch b.content {
+	case contentText:
+		message = b.buildText()
+	case contentMedia:
+		if b.client == nil {
+			return nil, errors.New("whatsapp client is not set")
+		}
+		built, err := BuildMediaMessage(ctx, b.client, b.media.kind, b.media.data, b.media.options)
+		if err != nil {
+			return nil, err
+		}
+		message = built
+	case contentLocation:
+		message = &waE2E.Message{LocationMessage: b.location}
+	case contentContact:
+		message = b.buildContacts()
+	case contentForward:
+		message = proto.Clone(b.forward).(*waE2E.Message)
+	case contentPoll:
+		message = proto.Clone(b.poll).(*waE2E.Message)
+	default:
+		return nil, ErrEmptyMessage
+	}
+
+	if info := b.contextInfo(messageContextInfo(message)); info != nil {
+		setContextInfo(message, info)
+	}
+	return message, nil
+}
+
+// Send membangun lalu mengirim pesan ke chat tujuan. Pesan tidak dikirim jika ctx
+// sudah berakhir, misalnya balasan terlambat dari command yang melewati batas waktu.
+func (b *MessageBuilder) Send(ctx context.Context) (whatsmeow.SendResponse, error) {
+	if b.client == nil {
+		return whatsmeow.SendResponse{}, errors.New("whatsapp client is not set")
+	}
+	if err := ctx.Err(); err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("message not sent: %v", err)
+	}
+	message, err := b.Build(ctx)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	return b.client.SendMessage(ctx, b.chat, message)
+}
+
+// setContent memilih jenis isi pesan. Error dicatat jika pesan sudah berisi jenis lain.
+func (b *MessageBuilder) setContent(content string) bool {
+	if b.content != "" && b.content != content {
+		if b.err == nil {
+			b.err = fmt.Errorf("message already has %s content, cannot add %s", b.content, content)
+		}
+		return false
+	}
+	b.content = content
+	return true
+}
+
+// buildText membuat pesan teks. Teks tanpa quote, mention atau link dikirim
+// sebagai Conversation biasa.
+func (b *MessageBuilder) buildText() *waE2E.Message {
+	if b.link == nil && b.quote == nil && len(b.mentions) == 0 {
+		return &waE2E.Message{Conversation: proto.String(b.text)}
+	}
+
+	text := b.text
+	extended := &waE2E.ExtendedTextMessage{}
+	if b.link != nil && b.link.URL != "" {
+		if !strings.Contains(text, b.link.URL) {
+			text = strings.TrimSpace(text + "\n" + b.link.URL)
+		}
+		extended.MatchedText = proto.String(b.link.URL)
+		extended.Title = proto.String(b.link.Title)
+		extended.Description = proto.String(b.link.Description)
+		extended.PreviewType = waE2E.ExtendedTextMessage_NONE.Enum()
+		extended.JPEGThumbnail = b.link.Thumbnail
+	}
+	extended.Text = proto.String(text)
+	return &waE2E.Message{ExtendedTextMessage: extended}
+}
+
+// buildContacts membuat pesan satu kontak atau daftar kontak
+func (b *MessageBuilder) buildContacts() *waE2E.Message {
+	if len(b.contacts) == 1 {
+		return &waE2E.Message{ContactMessage: b.contacts[0]}
+	}
+	return &waE2E.Message{ContactsArrayMessage: &waE2E.ContactsArrayMessage{
+		DisplayName: proto.String(fmt.Sprintf("%d kontak", len(b.contacts))),
+		Contacts:    b.contacts,
+	}}
+}
+
+// contextInfo menggabungkan quote dan mention ke ContextInfo yang sudah ada
+// (misalnya dari MediaOptions atau pesan yang diteruskan). Nil jika tidak ada yang perlu diisi.
+func (b *MessageBuilder) contextInfo(existing *waE2E.ContextInfo) *waE2E.ContextInfo {
+	if b.quote == nil && len(b.mentions) == 0 {
+		return existing
+	}
+
+	info := &waE2E.ContextInfo{}
+	if existing != nil {
+		info = proto.Clone(existing).(*waE2E.ContextInfo)
+	}
+	if b.quote != nil {
+		info.StanzaID = b.quote.StanzaID
+		info.Participant = b.quote.Participant
+		info.QuotedMessage = b.quote.QuotedMessage
+	}
+	for _, jid := range b.mentions {
+		if !containsString(info.MentionedJID, jid) {
+			info.MentionedJID = append(info.MentionedJID, jid)
+		}
+	}
+	return info
+}
+
+// cleanMessage menyalin isi pesan untuk dikutip atau diteruskan: pesan yang diedit
+// diganti versi terbarunya, data enkripsi dibuang, dan kutipan di dalamnya dihapus
+// agar rantai balasan tidak terus membesar
+func cleanMessage(message *waE2E.Message) *waE2E.Message {
+	if message == nil {
+		return nil
+	}
+
+	clean := proto.Clone(messageContent(message)).(*waE2E.Message)
+	clean.SenderKeyDistributionMessage = nil
+	clean.MessageContextInfo = nil
+
+	if info := messageContextInfo(clean); info != nil {
+		setContextInfo(clean, &waE2E.ContextInfo{
+			MentionedJID:    info.MentionedJID,
+			IsForwarded:     info.IsForwarded,
+			ForwardingScore: info.ForwardingScore,
+		})
+	}
+	return clean
+}
+
+// markForwarded menandai pesan sebagai pesan yang diteruskan dan menaikkan skornya
+func markForwarded(message *waE2E.Message) {
+	info := messageContextInfo(message)
+	if info == nil {
+		info = &waE2E.ContextInfo{}
+	}
+	info.IsForwarded = proto.Bool(true)
+	info.ForwardingScore = proto.Uint32(info.GetForwardingScore() + 1)
+	setContextInfo(message, info)
+}
+
+// setContextInfo memasang ContextInfo ke jenis pesan apa pun. Pesan Conversation
+// diubah menjadi ExtendedTextMessage karena Conversation tidak punya ContextInfo.
+func setContextInfo(message *waE2E.Message, info *waE2E.ContextInfo) {
+	switch {
+	case message.Conversation != nil:
+		message.ExtendedTextMessage = &waE2E.ExtendedTextMessage{Text: message.Conversation, ContextInfo: info}
+		message.Conversation = nil
+	case message.ExtendedTextMessage != nil:
+		message.ExtendedTextMessage.ContextInfo = info
+	case message.ImageMessage != nil:
+		message.ImageMessage.ContextInfo = info
+	case message.VideoMessage != nil:
+		message.VideoMessage.ContextInfo = info
+	case message.DocumentMessage != nil:
+		message.DocumentMessage.ContextInfo = info
+	case message.AudioMessage != nil:
+		message.AudioMessage.ContextInfo = info
+	case message.StickerMessage != nil:
+		message.StickerMessage.ContextInfo = info
+	case message.LocationMessage != nil:
+		message.LocationMessage.ContextInfo = info
+	case message.ContactMessage != nil:
+		message.ContactMessage.ContextInfo = info
+	case message.ContactsArrayMessage != nil:
+		message.ContactsArrayMessage.ContextInfo = info
+	case message.PollCreationMessage != nil:
+		message.PollCreationMessage.ContextInfo = info
+	}
+}
+
+// VCard membuat vCard sederhana dari nama dan nomor telepon. Nomor harus diawali
+// kode negara (misalnya "+62 812-3456-789"), spasi dan tanda baca diabaikan.
+func VCard(name, phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+
+	return fmt.Sprintf("BEGIN:VCARD\nVERSION:3.0\nFN:%s\nTEL;type=CELL;type=VOICE;waid=%s:+%s\nEND:VCARD", name, digits, digits)
+}
+
+// containsString mengecek apakah value ada di daftar
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"fmt"
+	"time"
+)
+
+// Handler adalah fungsi yang memproses satu command
+type Handler func(ctx *Context) error
+
+// Middleware membungkus Handler untuk menambahkan perilaku lintas plugin
+// (logging, izin, rate limit, metrik, dll). Middleware bisa menghentikan
+// dispatch dengan tidak memanggil next, misalnya setelah membalas user
+// lewat ctx.Reply.
+type Middleware func(next Handler) Handler
+
+// Chain membungkus handler dengan middleware. Middleware pertama menjadi
+// lapisan terluar sehingga dijalankan paling awal.
+func Chain(handler Handler, middleware ...Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// RecoverMiddleware mengubah panic di dalam plugin menjadi error
+// agar satu plugin yang bermasalah tidak menghentikan dispatch
+func RecoverMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic in plugin %s: %v", ctx.Command.Plugin.GetName(), r)
+				}
+			}()
+			return next(ctx)
+		}
+	}
+}
+
+// LoggingMiddleware mencatat setiap command beserta durasinya ke file log.
+// Error tetap dikembalikan ke pemanggil untuk ditangani seperti biasa.
+func LoggingMiddleware(errorHandler *ErrorHandler) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			startTime := time.Now()
+			err := next(ctx)
+
+			if errorHandler != nil {
+				context := fmt.Sprintf("command.%s", ctx.Command.Name)
+				summary := fmt.Sprintf("%s from %s in %s", ctx.Invoked, ctx.Sender, ctx.Chat)
+				if err != nil {
+					errorHandler.LogInfo(fmt.Sprintf("%s failed after %v: %v", summary, time.Since(startTime), err), context)
+				} else {
+					errorHandler.LogInfo(fmt.Sprintf("%s took %v", summary, time.Since(startTime)), context)
+				}
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Role adalah tingkat izin user. Role yang lebih tinggi mencakup role di bawahnya.
+type Role int
+
+const (
+	// RoleMember adalah user biasa, default untuk semua command
+	RoleMember Role = iota
+
+	// RoleGroupAdmin adalah admin grup tempat command dijalankan
+	RoleGroupAdmin
+
+	// RoleBotAdmin adalah admin bot yang ditunjuk owner
+	RoleBotAdmin
+
+	// RoleOwner adalah pemilik bot sesuai konfigurasi
+	RoleOwner
+)
+
+// String mengembalikan nama role
+func (r Role) String() string {
+	switch r {
+	case RoleMember:
+		return "member"
+	case RoleGroupAdmin:
+		return "group admin"
+	case RoleBotAdmin:
+		return "bot admin"
+	case RoleOwner:
+		return "owner"
+	default:
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+}
+
+const (
+	// roleBotAdmin dan roleBanned adalah nilai kolom role di database
+	roleBotAdmin = "bot_admin"
+	roleBanned   = "banned"
+)
+
+// PermissionConfig konfigurasi untuk sistem izin
+type PermissionConfig struct {
+	// Owners adalah daftar owner berupa nomor HP (628xxx) atau JID lengkap (xxx@lid)
+	Owners []string
+
+	// GroupCacheTTL adalah lama cache daftar admin grup
+	GroupCacheTTL time.Duration
+}
+
+// DefaultPermissionConfig konfigurasi default
+func DefaultPermissionConfig() *PermissionConfig {
+	return &PermissionConfig{
+		GroupCacheTTL: 5 * time.Minute,
+	}
+}
+
+// groupAdmins menyimpan cache admin satu grup
+type groupAdmins struct {
+	admins    map[string]bool
+	fetchedAt time.Time
+}
+
+// groupFetch adalah pengambilan metadata grup yang sedang berjalan. Pemanggil
+// lain untuk grup yang sama menunggu done dan memakai hasil yang sama.
+type groupFetch struct {
+	done  chan struct{}
+	group *groupAdmins
+	err   error
+}
+
+// PermissionManager menentukan role user dan menyimpan admin bot serta user yang diblokir
+type PermissionManager struct {
+	client *whatsmeow.Client
+	db     *sql.DB
+	config *PermissionConfig
+	owners map[string]bool
+
+	mu        sync.RWMutex
+	botAdmins map[string]bool
+	banned    map[string]bool
+
+	// groupMu hanya menjaga map di bawah; tidak pernah dipegang saat request ke server
+	groupMu      sync.Mutex
+	groups       map[types.JID]*groupAdmins
+	groupFetches map[types.JID]*groupFetch
+}
+
+// NewPermissionManager membuat instance baru PermissionManager dan memuat
+// data admin bot serta user yang diblokir dari database
+func NewPermissionManager(client *whatsmeow.Client, db *sql.DB, config *PermissionConfig) (*PermissionManager, error) {
+	if config == nil {
+		config = DefaultPermissionConfig()
+	}
+
+	pm := &PermissionManager{
+		client:       client,
+		db:           db,
+		config:       config,
+		owners:       make(map[string]bool),
+		botAdmins:    make(map[string]bool),
+		banned:       make(map[string]bool),
+		groups:       make(map[types.JID]*groupAdmins),
+		groupFetches: make(map[types.JID]*groupFetch),
+	}
+
+	for _, owner := range config.Owners {
+		jid, err := ParseUserJID(owner)
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner %q: %v", owner, err)
+		}
+		pm.owners[jid.String()] = true
+	}
+
+	if err := pm.initializeTable(); err != nil {
+		return nil, err
+	}
+	if err := pm.load(); err != nil {
+		return nil, err
+	}
+
+	return pm, nil
+}
+
+// ParseUserJID mengubah nomor HP atau JID menjadi JID user tanpa device
+func ParseUserJID(value string) (types.JID, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "@") {
+		jid, err := types.ParseJID(value)
+		if err != nil {
+			return types.EmptyJID, err
+		}
+		return jid.ToNonAD(), nil
+	}
+
+	phone := strings.NewReplacer("+", "", " ", "", "-", "").Replace(value)
+	if phone == "" {
+		return types.EmptyJID, fmt.Errorf("empty phone number")
+	}
+	for _, r := range phone {
+		if r < '0' || r > '9' {
+			return types.EmptyJID, fmt.Errorf("phone number must only contain digits")
+		}
+	}
+	return types.NewJID(phone, types.DefaultUserServer), nil
+}
+
+// initializeTable membuat tabel role jika belum ada
+func (pm *PermissionManager) initializeTable() error {
+	_, err := pm.db.Exec(`CREATE TABLE IF NOT EXISTS furina_user_roles (
+		jid  TEXT NOT NULL,
+		role TEXT NOT NULL,
+		PRIMARY KEY (jid, role)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create user roles table: %v", err)
+	}
+	return nil
+}
+
+// load memuat admin bot dan user yang diblokir dari database
+func (pm *PermissionManager) load() error {
+	rows, err := pm.db.Query(`SELECT jid, role FROM furina_user_roles`)
+	if err != nil {
+		return fmt.Errorf("failed to load user roles: %v", err)
+	}
+	defer rows.Close()
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for rows.Next() {
+		var jid, role string
+		if err := rows.Scan(&jid, &role); err != nil {
+			return fmt.Errorf("failed to read user role: %v", err)
+		}
+		switch role {
+		case roleBotAdmin:
+			pm.botAdmins[jid] = true
+		case roleBanned:
+			pm.banned[jid] = true
+		}
+	}
+	return rows.Err()
+}
+
+// setRole menambah atau menghapus role tersimpan untuk user
+func (pm *PermissionManager) setRole(jid types.JID, role string, enabled bool, cache map[string]bool) error {
+	key := jid.ToNonAD().String()
+
+	var err error
+	if enabled {
+		_, err = pm.db.Exec(`INSERT OR IGNORE INTO furina_user_roles (jid, role) VALUES (?, ?)`, key, role)
+	} else {
+		_, err = pm.db.Exec(`DELETE FROM furina_user_roles WHERE jid = ? AND role = ?`, key, role)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update role %s for %s: %v", role, key, err)
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if enabled {
+		cache[key] = true
+	} else {
+		delete(cache, key)
+	}
+	return nil
+}
+
+// SetBotAdmin menjadikan atau mencabut user sebagai admin bot
+func (pm *PermissionManager) SetBotAdmin(jid types.JID, admin bool) error {
+	return pm.setRole(jid, roleBotAdmin, admin, pm.botAdmins)
+}
+
+// Ban memblokir user sehingga semua command-nya diabaikan
+func (pm *PermissionManager) Ban(jid types.JID) error {
+	return pm.setRole(jid, roleBanned, true, pm.banned)
+}
+
+// Unban membuka blokir user
+func (pm *PermissionManager) Unban(jid types.JID) error {
+	return pm.setRole(jid, roleBanned, false, pm.banned)
+}
+
+// identities mengumpulkan semua JID yang mewakili pengirim pesan
+// (nomor HP dan LID) agar owner bisa dikonfigurasi dengan salah satunya
+func (pm *PermissionManager) identities(ctx context.Context, source types.MessageSource) []types.JID {
+	var jids []types.JID
+	add := func(jid types.JID) {
+		if jid.IsEmpty() {
+			return
+		}
+		jid = jid.ToNonAD()
+		for _, existing := range jids {
+			if existing == jid {
+				return
+			}
+		}
+		jids = append(jids, jid)
+	}
+
+	add(source.Sender)
+	add(source.SenderAlt)
+
+	// Lengkapi pasangan nomor HP/LID dari mapping yang disimpan whatsmeow
+	if pm.client != nil && pm.client.Store != nil && pm.client.Store.LIDs != nil {
+		for _, jid := range append([]types.JID(nil), jids...) {
+			var alt types.JID
+			switch jid.Server {
+			case types.HiddenUserServer:
+				alt, _ = pm.client.Store.LIDs.GetPNForLID(ctx, jid)
+			case types.DefaultUserServer:
+				alt, _ = pm.client.Store.LIDs.GetLIDForPN(ctx, jid)
+			}
+			add(alt)
+		}
+	}
+
+	return jids
+}
+
+// isOwner mengecek apakah salah satu identitas adalah owner atau akun bot sendiri
+func (pm *PermissionManager) isOwner(jids []types.JID) bool {
+	var self []types.JID
+	if pm.client != nil && pm.client.Store != nil {
+		if pm.client.Store.ID != nil {
+			self = append(self, pm.client.Store.ID.ToNonAD())
+		}
+		if !pm.client.Store.LID.IsEmpty() {
+			self = append(self, pm.client.Store.LID.ToNonAD())
+		}
+	}
+
+	for _, jid := range jids {
+		if pm.owners[jid.String()] {
+			return true
+		}
+		for _, own := range self {
+			if jid == own {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasStoredRole mengecek apakah salah satu identitas ada di cache role
+func (pm *PermissionManager) hasStoredRole(jids []types.JID, cache map[string]bool) bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	for _, jid := range jids {
+		if cache[jid.String()] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBanned mengecek apakah pengirim pesan diblokir
+func (pm *PermissionManager) IsBanned(ctx context.Context, source types.MessageSource) bool {
+	jids := pm.identities(ctx, source)
+	return !pm.isOwner(jids) && pm.hasStoredRole(jids, pm.banned)
+}
+
+// IsPrivileged mengecek apakah pengirim adalah owner atau admin bot
+// tanpa perlu mengambil metadata grup
+func (pm *PermissionManager) IsPrivileged(ctx context.Context, source types.MessageSource) bool {
+	jids := pm.identities(ctx, source)
+	return pm.isOwner(jids) || pm.hasStoredRole(jids, pm.botAdmins)
+}
+
+// RoleOf menentukan role tertinggi pengirim pesan
+func (pm *PermissionManager) RoleOf(ctx context.Context, source types.MessageSource) Role {
+	jids := pm.identities(ctx, source)
+
+	if pm.isOwner(jids) {
+		return RoleOwner
+	}
+	if pm.hasStoredRole(jids, pm.botAdmins) {
+		return RoleBotAdmin
+	}
+	if source.IsGroup && pm.isGroupAdmin(source.Chat, jids) {
+		return RoleGroupAdmin
+	}
+	return RoleMember
+}
+
+// isGroupAdmin mengecek status admin grup dari metadata grup yang di-cache
+func (pm *PermissionManager) isGroupAdmin(chat types.JID, jids []types.JID) bool {
+	group, err := pm.groupAdmins(chat)
+	if err != nil {
+		return false
+	}
+
+	for _, jid := range jids {
+		if group.admins[jid.String()] {
+			return true
+		}
+	}
+	return false
+}
+
+// groupAdmins mengambil daftar admin grup dari cache atau dari server. Request
+// ke server untuk grup yang sama digabung, dan grup lain tidak ikut menunggu.
+func (pm *PermissionManager) groupAdmins(chat types.JID) (*groupAdmins, error) {
+	pm.groupMu.Lock()
+	if cached, ok := pm.groups[chat]; ok && time.Since(cached.fetchedAt) < pm.config.GroupCacheTTL {
+		pm.groupMu.Unlock()
+		return cached, nil
+	}
+	if fetch, ok := pm.groupFetches[chat]; ok {
+		pm.groupMu.Unlock()
+		<-fetch.done
+		return fetch.group, fetch.err
+	}
+	fetch := &groupFetch{done: make(chan struct{})}
+	pm.groupFetches[chat] = fetch
+	pm.groupMu.Unlock()
+
+	fetch.group, fetch.err = pm.fetchGroupAdmins(chat)
+
+	pm.groupMu.Lock()
+	// Jika cache di-invalidate selama request berjalan, hasilnya tidak disimpan
+	if pm.groupFetches[chat] == fetch {
+		delete(pm.groupFetches, chat)
+		if fetch.err == nil {
+			pm.groups[chat] = fetch.group
+		}
+	}
+	pm.groupMu.Unlock()
+	close(fetch.done)
+
+	return fetch.group, fetch.err
+}
+
+// fetchGroupAdmins mengambil daftar admin grup dari server
+func (pm *PermissionManager) fetchGroupAdmins(chat types.JID) (*groupAdmins, error) {
+	info, err := pm.client.GetGroupInfo(chat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group info for %s: %v", chat, err)
+	}
+
+	group := &groupAdmins{
+		admins:    make(map[string]bool),
+		fetchedAt: time.Now(),
+	}
+	for _, participant := range info.Participants {
+		if !participant.IsAdmin && !participant.IsSuperAdmin {
+			continue
+		}
+		for _, jid := range []types.JID{participant.JID, participant.PhoneNumber, participant.LID} {
+			if !jid.IsEmpty() {
+				group.admins[jid.ToNonAD().String()] = true
+			}
+		}
+	}
+	return group, nil
+}
+
+// InvalidateGroup menghapus cache admin grup, misalnya setelah ada promote/demote
+func (pm *PermissionManager) InvalidateGroup(chat types.JID) {
+	pm.groupMu.Lock()
+	defer pm.groupMu.Unlock()
+
+	delete(pm.groups, chat)
+	delete(pm.groupFetches, chat)
+}
+
+// DeniedMessage mengembalikan pesan standar saat user tidak punya izin
+func DeniedMessage(required Role) string {
+	switch required {
+	case RoleGroupAdmin:
+		return "⛔ Command ini hanya bisa dipakai oleh admin grup."
+	case RoleBotAdmin:
+		return "⛔ Command ini hanya bisa dipakai oleh admin bot."
+	case RoleOwner:
+		return "⛔ Command ini hanya bisa dipakai oleh owner bot."
+	default:
+		return "⛔ Kamu tidak punya izin untuk memakai command ini."
+	}
+}
@@ -2,9 +2,12 @@ package lib
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"go.mau.fi/whatsmeow"
-	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
@@ -16,113 +19,478 @@ type Plugin interface {
 	// GetCommands mengembalikan daftar command yang didukung plugin
 	GetCommands() []string
 	
-	// HandleMessage menangani pesan yang masuk
-	HandleMessage(client *whatsmeow.Client, message *events.Message) error
+	// Handle menangani command yang sudah di-parse oleh PluginManager
+	Handle(ctx *Context) error
 	
 	// GetDescription mengembalikan deskripsi plugin
 	GetDescription() string
 }
 
+// LegacyPlugin adalah bentuk lama interface Plugin yang menerima event mentah.
+// Gunakan AdaptLegacyPlugin untuk mendaftarkannya ke PluginManager.
+type LegacyPlugin interface {
+	GetName() string
+	GetCommands() []string
+	HandleMessage(client *whatsmeow.Client, message *events.Message) error
+	GetDescription() string
+}
+
+// legacyPluginAdapter membungkus LegacyPlugin agar memenuhi interface Plugin
+type legacyPluginAdapter struct {
+	LegacyPlugin
+}
+
+// AdaptLegacyPlugin membungkus plugin dengan signature lama agar bisa didaftarkan
+func AdaptLegacyPlugin(plugin LegacyPlugin) Plugin {
+	return &legacyPluginAdapter{LegacyPlugin: plugin}
+}
+
+// Handle meneruskan command ke HandleMessage milik plugin lama
+func (a *legacyPluginAdapter) Handle(ctx *Context) error {
+	return a.LegacyPlugin.HandleMessage(ctx.Client, ctx.Event)
+}
+
+// Init meneruskan Init jika plugin lama mengimplementasikan Initializer
+func (a *legacyPluginAdapter) Init(ctx context.Context) error {
+	if initializer, ok := a.LegacyPlugin.(Initializer); ok {
+		return initializer.Init(ctx)
+	}
+	return nil
+}
+
+// Start meneruskan Start jika plugin lama mengimplementasikan Starter
+func (a *legacyPluginAdapter) Start(ctx context.Context) error {
+	if starter, ok := a.LegacyPlugin.(Starter); ok {
+		return starter.Start(ctx)
+	}
+	return nil
+}
+
+// Stop meneruskan Stop jika plugin lama mengimplementasikan Stopper
+func (a *legacyPluginAdapter) Stop(ctx context.Context) error {
+	if stopper, ok := a.LegacyPlugin.(Stopper); ok {
+		return stopper.Stop(ctx)
+	}
+	return nil
+}
+
+// HealthCheck meneruskan HealthCheck jika plugin lama mengimplementasikan HealthChecker.
+// Plugin yang tidak mengimplementasikannya dilewati PluginManager.HealthCheck.
+func (a *legacyPluginAdapter) HealthCheck(ctx context.Context) error {
+	if checker, ok := a.LegacyPlugin.(HealthChecker); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
+
+// Restart meneruskan Restart jika plugin lama mengimplementasikan Restarter
+func (a *legacyPluginAdapter) Restart(ctx context.Context) error {
+	if restarter, ok := a.LegacyPlugin.(Restarter); ok {
+		return restarter.Restart(ctx)
+	}
+	return fmt.Errorf("plugin %s does not support restart", a.GetName())
+}
+
+// IsEssential meneruskan IsEssential jika plugin lama mengimplementasikan EssentialPlugin
+func (a *legacyPluginAdapter) IsEssential() bool {
+	if essential, ok := a.LegacyPlugin.(EssentialPlugin); ok {
+		return essential.IsEssential()
+	}
+	return false
+}
+
+// GetListeners meneruskan listener jika plugin lama mengimplementasikan ListenerProvider
+func (a *legacyPluginAdapter) GetListeners() []Listener {
+	if provider, ok := a.LegacyPlugin.(ListenerProvider); ok {
+		return provider.GetListeners()
+	}
+	return nil
+}
+
+// SubscribeEvents meneruskan subscription jika plugin lama mengimplementasikan EventSubscriber
+func (a *legacyPluginAdapter) SubscribeEvents(scope *EventScope) {
+	if subscriber, ok := a.LegacyPlugin.(EventSubscriber); ok {
+		subscriber.SubscribeEvents(scope)
+	}
+}
+
+// GetFlows meneruskan flow jika plugin lama mengimplementasikan FlowProvider
+func (a *legacyPluginAdapter) GetFlows() []Flow {
+	if provider, ok := a.LegacyPlugin.(FlowProvider); ok {
+		return provider.GetFlows()
+	}
+	return nil
+}
+
+// GetCommandSpecs meneruskan metadata command jika plugin lama mendeklarasikannya
+func (a *legacyPluginAdapter) GetCommandSpecs() []CommandSpec {
+	if provider, ok := a.LegacyPlugin.(CommandSpecProvider); ok {
+		return provider.GetCommandSpecs()
+	}
+
+	var specs []CommandSpec
+	for _, cmd := range a.LegacyPlugin.GetCommands() {
+		specs = append(specs, CommandSpec{Name: cmd})
+	}
+	return specs
+}
+
 // PluginManager mengelola semua plugin
 type PluginManager struct {
-	plugins       map[string]Plugin
+	registry      *CommandRegistry
 	client        *whatsmeow.Client
 	commandParser *CommandParser
+
+	permissions   *PermissionManager
+	rateLimiter   *RateLimiter
+	cooldowns     *CooldownManager
+	pluginState   *PluginStateStore
+	chatSettings  *ChatSettings
+	unknownStats  *UnknownCommandStats
+	eventBus      *EventBus
+	dispatcher    *Dispatcher
+	conversations *ConversationManager
+	media         *MediaManager
+
+	lifecycleTimeout time.Duration
+	commandTimeout   time.Duration
+	feedback         FeedbackConfig
+
+	middlewareMu     sync.RWMutex
+	middleware       []Middleware
+	pluginMiddleware map[string][]Middleware
 }
 
-// NewPluginManager membuat instance baru PluginManager
-func NewPluginManager(client *whatsmeow.Client) *PluginManager {
+// NewPluginManager membuat instance baru PluginManager.
+// Config menentukan prefix command; nil berarti DefaultCommandConfig.
+func NewPluginManager(client *whatsmeow.Client, config *CommandConfig) *PluginManager {
+	if config == nil {
+		config = DefaultCommandConfig()
+	}
 	return &PluginManager{
-		plugins:       make(map[string]Plugin),
+		registry:      NewCommandRegistry(config.CaseSensitive),
 		client:        client,
-		commandParser: NewCommandParser(DefaultCommandConfig()),
+		commandParser: NewCommandParser(config),
+
+		lifecycleTimeout: DefaultLifecycleTimeout,
+		feedback:         DefaultFeedbackConfig(),
+		pluginMiddleware: make(map[string][]Middleware),
 	}
 }
 
-// RegisterPlugin mendaftarkan plugin baru
-func (pm *PluginManager) RegisterPlugin(plugin Plugin) {
-	pm.plugins[plugin.GetName()] = plugin
+// SetPermissionManager mengaktifkan pengecekan izin terpusat saat dispatch
+func (pm *PluginManager) SetPermissionManager(permissions *PermissionManager) {
+	pm.permissions = permissions
 }
 
-// HandleMessage menangani pesan dan meneruskan ke plugin yang sesuai
-func (pm *PluginManager) HandleMessage(message *events.Message) error {
-	if message.Info.IsFromMe || message.Message.GetConversation() == "" {
-		return nil
+// Permissions mengembalikan permission manager yang aktif (bisa nil)
+func (pm *PluginManager) Permissions() *PermissionManager {
+	return pm.permissions
+}
+
+// SetRateLimiter mengaktifkan rate limit dan hukuman anti-spam saat dispatch
+func (pm *PluginManager) SetRateLimiter(rateLimiter *RateLimiter) {
+	pm.rateLimiter = rateLimiter
+}
+
+// RateLimiter mengembalikan rate limiter yang aktif (bisa nil)
+func (pm *PluginManager) RateLimiter() *RateLimiter {
+	return pm.rateLimiter
+}
+
+// SetCooldownManager mengaktifkan cooldown per command yang dideklarasikan plugin
+func (pm *PluginManager) SetCooldownManager(cooldowns *CooldownManager) {
+	pm.cooldowns = cooldowns
+}
+
+// Use menambahkan middleware global yang membungkus dispatch semua command.
+// Middleware dijalankan sesuai urutan pendaftaran.
+func (pm *PluginManager) Use(middleware ...Middleware) {
+	pm.middlewareMu.Lock()
+	defer pm.middlewareMu.Unlock()
+
+	pm.middleware = append(pm.middleware, middleware...)
+}
+
+// UsePlugin menambahkan middleware yang hanya membungkus command milik
+// plugin tertentu. Middleware ini berjalan di dalam middleware global.
+func (pm *PluginManager) UsePlugin(pluginName string, middleware ...Middleware) {
+	pm.middlewareMu.Lock()
+	defer pm.middlewareMu.Unlock()
+
+	pm.pluginMiddleware[pluginName] = append(pm.pluginMiddleware[pluginName], middleware...)
+}
+
+// buildHandler menyusun rantai middleware untuk plugin tertentu
+func (pm *PluginManager) buildHandler(plugin Plugin) Handler {
+	pm.middlewareMu.RLock()
+	defer pm.middlewareMu.RUnlock()
+
+	handler := Chain(func(ctx *Context) error {
+		if ctx.Subcommand != nil {
+			return ctx.Subcommand.Handler(ctx)
+		}
+		return plugin.Handle(ctx)
+	}, pm.guards()...)
+	handler = Chain(handler, pm.pluginMiddleware[plugin.GetName()]...)
+	return Chain(handler, pm.middleware...)
+}
+
+// guards mengembalikan pengecekan bawaan yang selalu dijalankan tepat
+// sebelum plugin dipanggil, setelah semua middleware
+func (pm *PluginManager) guards() []Middleware {
+	return []Middleware{pm.pluginStateGuard, pm.banGuard, pm.rateLimitGuard, pm.permissionGuard, pm.subcommandGuard, pm.argsGuard, pm.cooldownGuard, pm.feedbackGuard}
+}
+
+// banGuard mengabaikan command dari user yang diblokir tanpa balasan agar tidak memancing spam
+func (pm *PluginManager) banGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		if pm.permissions != nil && pm.permissions.IsBanned(ctx.Ctx, ctx.Event.Info.MessageSource) {
+			return nil
+		}
+		return next(ctx)
 	}
+}
 
-	messageText := message.Message.GetConversation()
-	
-	// Parse command menggunakan command parser
-	command, _, isCommand := pm.commandParser.ParseCommand(messageText)
-	if !isCommand {
-		return nil
+// rateLimitGuard membatasi frekuensi command dan menerapkan hukuman anti-spam
+func (pm *PluginManager) rateLimitGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		if allowed, err := pm.checkRateLimit(ctx, ctx.Command.Name, ctx.Command.RateLimit); !allowed {
+			return err
+		}
+		return next(ctx)
 	}
+}
 
-	// Cari plugin yang menangani command ini
-	for _, plugin := range pm.plugins {
-		for _, cmd := range plugin.GetCommands() {
-			if cmd == command {
-				return plugin.HandleMessage(pm.client, message)
+// checkRateLimit mengecek rate limit pengirim ctx dan membalas sesuai hukumannya.
+// Mengembalikan false jika pesan tidak boleh diproses.
+func (pm *PluginManager) checkRateLimit(ctx *Context, command string, limit *RateLimit) (bool, error) {
+	if pm.rateLimiter == nil {
+		return true, nil
+	}
+
+	// Owner dan admin bot tidak dibatasi
+	if pm.permissions != nil && pm.permissions.IsPrivileged(ctx.Ctx, ctx.Event.Info.MessageSource) {
+		return true, nil
+	}
+
+	verdict, err := pm.rateLimiter.Check(ctx.Sender, ctx.Chat, command, limit)
+	if err != nil {
+		return false, err
+	}
+
+	switch verdict {
+	case RateAllowed:
+		return true, nil
+	case RateWarned:
+		return false, ctx.Reply("⚠️ Pelan-pelan! Kamu mengirim command terlalu cepat. Jika diteruskan, bot akan mengabaikanmu sementara.")
+	case RateIgnored:
+		return false, ctx.Replyf("🔇 Kamu diabaikan selama %s karena spam command.", FormatDuration(pm.rateLimiter.IgnoredFor(ctx.Sender)))
+	case RateBlacklisted:
+		if pm.permissions != nil {
+			if err := pm.permissions.Ban(ctx.Sender); err != nil {
+				return false, err
 			}
 		}
+		return false, ctx.Reply("🚫 Kamu diblokir otomatis karena terus melakukan spam command.")
+	default:
+		return false, nil
+	}
+}
+
+// permissionGuard menolak command dari user yang tidak punya role yang dibutuhkan
+func (pm *PluginManager) permissionGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		if pm.permissions == nil {
+			return next(ctx)
+		}
+
+		if required := ctx.Command.Role; required > RoleMember && ctx.Role() < required {
+			return ctx.Reply(DeniedMessage(required))
+		}
+
+		return next(ctx)
+	}
+}
+
+// cooldownGuard membalas dengan sisa waktu tunggu jika command masih dalam cooldown
+func (pm *PluginManager) cooldownGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		if pm.cooldowns == nil || ctx.Command.Cooldown == nil {
+			return next(ctx)
+		}
+
+		// Owner dan admin bot tidak terkena cooldown
+		if pm.permissions != nil && pm.permissions.IsPrivileged(ctx.Ctx, ctx.Event.Info.MessageSource) {
+			return next(ctx)
+		}
+
+		remaining, err := pm.cooldowns.Acquire(ctx.Command.Name, *ctx.Command.Cooldown, ctx.Sender, ctx.Chat)
+		if err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return ctx.Replyf("⏳ Tunggu %s lagi sebelum memakai %s%s.", FormatDuration(remaining), ctx.Prefix, ctx.Command.Name)
+		}
+
+		return next(ctx)
+	}
+}
+
+// RegisterPlugin mendaftarkan plugin baru beserta command-nya.
+// Mengembalikan error jika nama plugin, command, atau alias sudah dipakai.
+func (pm *PluginManager) RegisterPlugin(plugin Plugin) error {
+	if err := pm.checkFlows(plugin); err != nil {
+		return err
+	}
+	if err := pm.registry.Register(plugin); err != nil {
+		return err
+	}
+	if err := pm.registerFlows(plugin); err != nil {
+		pm.registry.Unregister(plugin.GetName())
+		return err
+	}
+	pm.subscribePlugin(plugin)
+	return nil
+}
+
+// HandleMessage menangani pesan: menjalankan listener yang terpicu lalu
+// meneruskan command ke plugin yang sesuai. Membatalkan parent menghentikan
+// listener dan command yang sedang berjalan untuk pesan ini.
+func (pm *PluginManager) HandleMessage(parent context.Context, message *events.Message) error {
+	if message.Info.IsFromMe {
+		return nil
+	}
+
+	messageText := MessageText(message)
+
+	ctx := &Context{
+		Ctx:     parent,
+		Client:  pm.client,
+		Manager: pm,
+		Event:   message,
+		RawText: messageText,
+		Quoted:  quotedMessage(message.Message, pm.media),
+		Media:   newMedia(pm.media, message.Message),
+		Prefix:  pm.commandParser.Prefix(),
+		Sender:  message.Info.Sender,
+		Chat:    message.Info.Chat,
+		IsGroup: message.Info.IsGroup,
 	}
 
+	// Pesan dari user yang sedang dalam percakapan hanya diteruskan ke flow
+	if consumed, err := pm.runConversation(ctx); consumed || err != nil {
+		return err
+	}
+
+	// Parse command menggunakan command parser
+	command, argText, tokens, isCommand := pm.commandParser.parse(messageText)
+
+	// Listener berjalan lebih dulu agar moderasi bisa menghentikan command
+	stopped, err := pm.runListeners(ctx, isCommand)
+	if stopped || !isCommand {
+		return err
+	}
+
+	ctx.Invoked = command
+	ctx.Args = make([]string, len(tokens))
+	for i, token := range tokens {
+		ctx.Args[i] = token.Value
+	}
+	ctx.argText = argText
+	ctx.tokens = tokens
+
+	// Cari plugin yang menangani command ini melalui indeks registry
+	entry, ok := pm.registry.Lookup(command)
+	if !ok {
+		return errors.Join(err, pm.handleUnknownCommand(ctx))
+	}
+	ctx.Command = entry
+
+	// Reaksi status dan status mengetik dimulai feedbackGuard dan diakhiri di sini,
+	// termasuk saat command timeout
+	ctx.feedback = pm.newFeedback(ctx)
+	commandErr := pm.runCommand(ctx, pm.buildHandler(entry.Plugin))
+	ctx.feedback.finish(parent, commandErr)
+
+	return errors.Join(err, commandErr)
+}
+
+// CommandParser mengembalikan parser yang dipakai untuk mengenali command
+func (pm *PluginManager) CommandParser() *CommandParser {
+	return pm.commandParser
+}
+
+// Client mengembalikan client WhatsApp yang dipakai plugin manager, misalnya untuk
+// plugin yang mengirim pesan dari goroutine latar belakang
+func (pm *PluginManager) Client() *whatsmeow.Client {
+	return pm.client
+}
+
+// ReplacePlugin mengganti plugin terdaftar dengan nama yang sama beserta command,
+// flow dan subscription event-nya. Flow diperiksa lebih dulu dan registry diganti
+// secara atomik, jadi jika ada yang tidak valid plugin lama tetap dipakai utuh.
+func (pm *PluginManager) ReplacePlugin(plugin Plugin) error {
+	if err := pm.checkFlows(plugin); err != nil {
+		return err
+	}
+	if err := pm.registry.Replace(plugin); err != nil {
+		return err
+	}
+	if err := pm.registerFlows(plugin); err != nil {
+		return err
+	}
+	pm.unsubscribePlugin(plugin.GetName())
+	pm.subscribePlugin(plugin)
 	return nil
 }
 
+// UnregisterPlugin menghapus plugin beserta command, flow dan subscription event-nya dari registry
+func (pm *PluginManager) UnregisterPlugin(name string) bool {
+	if !pm.registry.Unregister(name) {
+		return false
+	}
+	pm.unregisterFlows(name)
+	pm.unsubscribePlugin(name)
+	return true
+}
+
+// LookupCommand mencari command terdaftar berdasarkan nama atau alias
+func (pm *PluginManager) LookupCommand(name string) (*Command, bool) {
+	return pm.registry.Lookup(name)
+}
+
+// ListCommands mengembalikan semua command terdaftar sesuai urutan registrasi
+func (pm *PluginManager) ListCommands() []*Command {
+	return pm.registry.Commands()
+}
+
+// GetPlugin mencari plugin berdasarkan nama
+func (pm *PluginManager) GetPlugin(name string) (Plugin, bool) {
+	return pm.registry.Plugin(name)
+}
+
+// GetPlugins mengembalikan semua plugin sesuai urutan registrasi
+func (pm *PluginManager) GetPlugins() []Plugin {
+	return pm.registry.Plugins()
+}
+
 // GetAllPlugins mengembalikan semua plugin yang terdaftar
 func (pm *PluginManager) GetAllPlugins() map[string]Plugin {
-	return pm.plugins
+	plugins := make(map[string]Plugin)
+	for _, plugin := range pm.registry.Plugins() {
+		plugins[plugin.GetName()] = plugin
+	}
+	return plugins
 }
 
 // SendReply mengirim pesan balasan dengan quote/reply
-func (pm *PluginManager) SendReply(message *events.Message, responseText string) error {
-	senderJID := message.Info.Sender
-	chatJID := message.Info.Chat
-	messageText := message.Message.GetConversation()
-	senderJIDString := senderJID.String()
-
-	// Buat pesan dengan quote/reply
-	replyMessage := &waE2E.Message{
-		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
-			Text: &responseText,
-			ContextInfo: &waE2E.ContextInfo{
-				StanzaID:    &message.Info.ID,
-				Participant: &senderJIDString,
-				QuotedMessage: &waE2E.Message{
-					Conversation: &messageText,
-				},
-			},
-		},
-	}
-
-	// Kirim balasan dengan reply
-	_, err := pm.client.SendMessage(context.Background(), chatJID, replyMessage)
-	return err
+func (pm *PluginManager) SendReply(ctx context.Context, message *events.Message, responseText string) error {
+	return SendReplyMessage(ctx, pm.client, message, responseText)
 }
 
 // SendSimpleReply mengirim pesan balasan sederhana dengan quote
-func SendReplyMessage(client *whatsmeow.Client, message *events.Message, responseText string) error {
-	senderJID := message.Info.Sender
-	chatJID := message.Info.Chat
-	messageText := message.Message.GetConversation()
-	senderJIDString := senderJID.String()
-
-	// Buat pesan dengan quote/reply
-	replyMessage := &waE2E.Message{
-		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
-			Text: &responseText,
-			ContextInfo: &waE2E.ContextInfo{
-				StanzaID:    &message.Info.ID,
-				Participant: &senderJIDString,
-				QuotedMessage: &waE2E.Message{
-					Conversation: &messageText,
-				},
-			},
-		},
-	}
-
-	// Kirim balasan dengan reply
-	_, err := client.SendMessage(context.Background(), chatJID, replyMessage)
+func SendReplyMessage(ctx context.Context, client *whatsmeow.Client, message *events.Message, responseText string) error {
+	_, err := NewMessage(client, message.Info.Chat).Text(responseText).Quote(message).Send(ctx)
 	return err
 }
\ No newline at end of file
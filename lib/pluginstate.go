@@ -0,0 +1,205 @@
+package lib
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	// ErrPluginNotFound dikembalikan saat plugin yang dimaksud tidak terdaftar
+	ErrPluginNotFound = errors.New("plugin not found")
+
+	// ErrPluginEssential dikembalikan saat mencoba menonaktifkan plugin yang wajib aktif
+	ErrPluginEssential = errors.New("plugin cannot be disabled")
+)
+
+// EssentialPlugin bisa diimplementasikan plugin yang tidak boleh dinonaktifkan,
+// misalnya plugin yang dipakai untuk mengaktifkan kembali plugin lain
+type EssentialPlugin interface {
+	IsEssential() bool
+}
+
+// PluginStateStore menyimpan plugin yang dinonaktifkan secara global atau per chat.
+// Chat kosong (types.EmptyJID) berarti global.
+type PluginStateStore struct {
+	db *sql.DB
+
+	mu       sync.RWMutex
+	disabled map[string]map[string]bool
+}
+
+// NewPluginStateStore membuat instance baru PluginStateStore dan memuat status dari database
+func NewPluginStateStore(db *sql.DB) (*PluginStateStore, error) {
+	ps := &PluginStateStore{
+		db:       db,
+		disabled: make(map[string]map[string]bool),
+	}
+
+	if err := ps.initializeTable(); err != nil {
+		return nil, err
+	}
+	if err := ps.load(); err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// initializeTable membuat tabel status plugin jika belum ada
+func (ps *PluginStateStore) initializeTable() error {
+	_, err := ps.db.Exec(`CREATE TABLE IF NOT EXISTS furina_disabled_plugins (
+		plugin TEXT NOT NULL,
+		chat   TEXT NOT NULL,
+		PRIMARY KEY (plugin, chat)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create plugin state table: %v", err)
+	}
+	return nil
+}
+
+// load memuat daftar plugin nonaktif dari database
+func (ps *PluginStateStore) load() error {
+	rows, err := ps.db.Query(`SELECT plugin, chat FROM furina_disabled_plugins`)
+	if err != nil {
+		return fmt.Errorf("failed to load plugin state: %v", err)
+	}
+	defer rows.Close()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for rows.Next() {
+		var plugin, chat string
+		if err := rows.Scan(&plugin, &chat); err != nil {
+			return fmt.Errorf("failed to read plugin state: %v", err)
+		}
+		ps.markLocked(plugin, chat, true)
+	}
+	return rows.Err()
+}
+
+// markLocked memperbarui cache. Harus dipanggil dengan mu terkunci.
+func (ps *PluginStateStore) markLocked(plugin, chat string, disabled bool) {
+	if disabled {
+		if ps.disabled[plugin] == nil {
+			ps.disabled[plugin] = make(map[string]bool)
+		}
+		ps.disabled[plugin][chat] = true
+		return
+	}
+
+	delete(ps.disabled[plugin], chat)
+	if len(ps.disabled[plugin]) == 0 {
+		delete(ps.disabled, plugin)
+	}
+}
+
+// chatKey mengubah JID chat menjadi kunci penyimpanan ("" untuk global)
+func chatKey(chat types.JID) string {
+	if chat.IsEmpty() {
+		return ""
+	}
+	return chat.ToNonAD().String()
+}
+
+// SetEnabled mengaktifkan atau menonaktifkan plugin secara global atau di satu chat
+func (ps *PluginStateStore) SetEnabled(plugin string, chat types.JID, enabled bool) error {
+	key := chatKey(chat)
+
+	var err error
+	if enabled {
+		_, err = ps.db.Exec(`DELETE FROM furina_disabled_plugins WHERE plugin = ? AND chat = ?`, plugin, key)
+	} else {
+		_, err = ps.db.Exec(`INSERT OR IGNORE INTO furina_disabled_plugins (plugin, chat) VALUES (?, ?)`, plugin, key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update state of plugin %s: %v", plugin, err)
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.markLocked(plugin, key, !enabled)
+	return nil
+}
+
+// IsDisabledGlobally mengecek apakah plugin dinonaktifkan untuk semua chat
+func (ps *PluginStateStore) IsDisabledGlobally(plugin string) bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.disabled[plugin][""]
+}
+
+// IsDisabledInChat mengecek apakah plugin dinonaktifkan khusus di chat tertentu
+func (ps *PluginStateStore) IsDisabledInChat(plugin string, chat types.JID) bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.disabled[plugin][chatKey(chat)]
+}
+
+// IsEnabled mengecek apakah plugin aktif di chat. Status global selalu menang.
+func (ps *PluginStateStore) IsEnabled(plugin string, chat types.JID) bool {
+	return !ps.IsDisabledGlobally(plugin) && !ps.IsDisabledInChat(plugin, chat)
+}
+
+// SetPluginStateStore mengaktifkan pengaturan plugin aktif/nonaktif saat dispatch
+func (pm *PluginManager) SetPluginStateStore(state *PluginStateStore) {
+	pm.pluginState = state
+}
+
+// PluginStateStore mengembalikan penyimpanan status plugin yang aktif (bisa nil)
+func (pm *PluginManager) PluginStateStore() *PluginStateStore {
+	return pm.pluginState
+}
+
+// IsPluginEnabled mengecek apakah plugin aktif di chat tertentu
+func (pm *PluginManager) IsPluginEnabled(name string, chat types.JID) bool {
+	if pm.pluginState == nil {
+		return true
+	}
+	return pm.pluginState.IsEnabled(name, chat)
+}
+
+// EnablePlugin mengaktifkan plugin. Chat kosong berarti global.
+func (pm *PluginManager) EnablePlugin(name string, chat types.JID) error {
+	return pm.setPluginEnabled(name, chat, true)
+}
+
+// DisablePlugin menonaktifkan plugin. Chat kosong berarti global.
+func (pm *PluginManager) DisablePlugin(name string, chat types.JID) error {
+	return pm.setPluginEnabled(name, chat, false)
+}
+
+// setPluginEnabled memvalidasi plugin lalu menyimpan statusnya
+func (pm *PluginManager) setPluginEnabled(name string, chat types.JID, enabled bool) error {
+	if pm.pluginState == nil {
+		return fmt.Errorf("plugin state store is not configured")
+	}
+
+	plugin, ok := pm.registry.Plugin(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+	}
+	if essential, ok := plugin.(EssentialPlugin); ok && essential.IsEssential() && !enabled {
+		return fmt.Errorf("%w: %s", ErrPluginEssential, name)
+	}
+
+	return pm.pluginState.SetEnabled(name, chat, enabled)
+}
+
+// pluginStateGuard mengabaikan command dari plugin yang dinonaktifkan
+func (pm *PluginManager) pluginStateGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		if !pm.IsPluginEnabled(ctx.Command.Plugin.GetName(), ctx.Chat) {
+			return nil
+		}
+		return next(ctx)
+	}
+}
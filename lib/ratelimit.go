@@ -0,0 +1,365 @@
+package lib
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// RateLimit mendefinisikan token bucket: Rate token per detik dengan kapasitas Burst
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig konfigurasi rate limiter dan hukuman anti-spam
+type RateLimitConfig struct {
+	// User adalah batas command per pengirim di semua chat
+	User RateLimit
+
+	// Chat adalah batas command per chat dari semua pengirim
+	Chat RateLimit
+
+	// StrikeWindow adalah jangka waktu pelanggaran dihitung sebelum direset
+	StrikeWindow time.Duration
+
+	// IgnoreAfter adalah jumlah pelanggaran sebelum user diabaikan sementara
+	IgnoreAfter int
+
+	// IgnoreDuration adalah lama abaian pertama, berlipat dua di setiap abaian berikutnya
+	IgnoreDuration time.Duration
+
+	// BlacklistAfter adalah jumlah abaian sebelum user diblokir otomatis (0 = tidak pernah)
+	BlacklistAfter int
+}
+
+// DefaultRateLimitConfig konfigurasi default
+func DefaultRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		User:           RateLimit{Rate: 0.5, Burst: 5},
+		Chat:           RateLimit{Rate: 2, Burst: 20},
+		StrikeWindow:   10 * time.Minute,
+		IgnoreAfter:    3,
+		IgnoreDuration: time.Minute,
+		BlacklistAfter: 3,
+	}
+}
+
+// RateLimitVerdict adalah hasil pengecekan rate limiter
+type RateLimitVerdict int
+
+const (
+	// RateAllowed berarti command boleh dijalankan
+	RateAllowed RateLimitVerdict = iota
+
+	// RateDropped berarti command dibuang tanpa balasan
+	RateDropped
+
+	// RateWarned berarti user melanggar batas dan perlu diperingatkan
+	RateWarned
+
+	// RateIgnored berarti user baru saja mulai diabaikan sementara
+	RateIgnored
+
+	// RateBlacklisted berarti user melanggar terlalu sering dan harus diblokir
+	RateBlacklisted
+)
+
+// tokenBucket menyimpan sisa token satu kunci
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// penalty menyimpan status hukuman satu user
+type penalty struct {
+	strikes      int
+	ignores      int
+	ignoredUntil time.Time
+	lastStrike   time.Time
+}
+
+// RateLimiter membatasi command per user, per chat dan per command,
+// serta memberi hukuman bertingkat yang disimpan di database
+type RateLimiter struct {
+	db     *sql.DB
+	config *RateLimitConfig
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	penalties map[string]*penalty
+	lastPrune time.Time
+}
+
+// NewRateLimiter membuat instance baru RateLimiter dan memuat status hukuman dari database
+func NewRateLimiter(db *sql.DB, config *RateLimitConfig) (*RateLimiter, error) {
+	if config == nil {
+		config = DefaultRateLimitConfig()
+	}
+
+	rl := &RateLimiter{
+		db:        db,
+		config:    config,
+		buckets:   make(map[string]*tokenBucket),
+		penalties: make(map[string]*penalty),
+		lastPrune: time.Now(),
+	}
+
+	if err := rl.initializeTable(); err != nil {
+		return nil, err
+	}
+	if err := rl.load(); err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// initializeTable membuat tabel hukuman jika belum ada
+func (rl *RateLimiter) initializeTable() error {
+	_, err := rl.db.Exec(`CREATE TABLE IF NOT EXISTS furina_penalties (
+		jid           TEXT PRIMARY KEY,
+		strikes       INTEGER NOT NULL,
+		ignores       INTEGER NOT NULL,
+		ignored_until INTEGER NOT NULL,
+		last_strike   INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create penalties table: %v", err)
+	}
+	return nil
+}
+
+// load memuat status hukuman dari database
+func (rl *RateLimiter) load() error {
+	rows, err := rl.db.Query(`SELECT jid, strikes, ignores, ignored_until, last_strike FROM furina_penalties`)
+	if err != nil {
+		return fmt.Errorf("failed to load penalties: %v", err)
+	}
+	defer rows.Close()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for rows.Next() {
+		var jid string
+		var ignoredUntil, lastStrike int64
+		p := &penalty{}
+		if err := rows.Scan(&jid, &p.strikes, &p.ignores, &ignoredUntil, &lastStrike); err != nil {
+			return fmt.Errorf("failed to read penalty: %v", err)
+		}
+		p.ignoredUntil = time.Unix(ignoredUntil, 0)
+		p.lastStrike = time.Unix(lastStrike, 0)
+		rl.penalties[jid] = p
+	}
+	return rows.Err()
+}
+
+// savePenalty menyimpan status hukuman user ke database
+func (rl *RateLimiter) savePenalty(key string, p *penalty) error {
+	_, err := rl.db.Exec(`INSERT INTO furina_penalties (jid, strikes, ignores, ignored_until, last_strike)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (jid) DO UPDATE SET strikes = excluded.strikes, ignores = excluded.ignores,
+			ignored_until = excluded.ignored_until, last_strike = excluded.last_strike`,
+		key, p.strikes, p.ignores, p.ignoredUntil.Unix(), p.lastStrike.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save penalty for %s: %v", key, err)
+	}
+	return nil
+}
+
+// refill mengambil bucket lalu mengisi ulang token sesuai waktu yang berlalu.
+// Mengembalikan nil jika limit tidak aktif.
+func (rl *RateLimiter) refill(key string, limit RateLimit, now time.Time) *tokenBucket {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return nil
+	}
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		rl.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+elapsed*limit.Rate)
+	bucket.last = now
+	return bucket
+}
+
+// available mengecek apakah bucket masih punya token (bucket nil selalu tersedia)
+func (b *tokenBucket) available() bool {
+	return b == nil || b.tokens >= 1
+}
+
+// spend memakai satu token dari bucket
+func (b *tokenBucket) spend() {
+	if b != nil {
+		b.tokens--
+	}
+}
+
+// prune membuang bucket yang sudah penuh kembali agar map tidak terus membesar
+func (rl *RateLimiter) prune(now time.Time) {
+	if now.Sub(rl.lastPrune) < time.Minute {
+		return
+	}
+	rl.lastPrune = now
+
+	for key, bucket := range rl.buckets {
+		if now.Sub(bucket.last) > 10*time.Minute {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// Check mengecek apakah sender boleh menjalankan command di chat.
+// Limit tambahan khusus command bisa diberikan lewat commandLimit (boleh nil).
+func (rl *RateLimiter) Check(sender, chat types.JID, command string, commandLimit *RateLimit) (RateLimitVerdict, error) {
+	now := time.Now()
+	userKey := sender.ToNonAD().String()
+
+	rl.mu.Lock()
+	verdict, saved := rl.check(userKey, chat, command, commandLimit, now)
+	rl.mu.Unlock()
+
+	// Hukuman disimpan setelah lock dilepas agar Check lain tidak menunggu disk
+	if saved != nil {
+		return verdict, rl.savePenalty(userKey, saved)
+	}
+	return verdict, nil
+}
+
+// check mengecek bucket dan mencatat pelanggaran; rl.mu harus dipegang pemanggil.
+// Salinan hukuman yang perlu disimpan dikembalikan jika ada pelanggaran.
+func (rl *RateLimiter) check(userKey string, chat types.JID, command string, commandLimit *RateLimit, now time.Time) (RateLimitVerdict, *penalty) {
+	rl.prune(now)
+
+	p := rl.penalties[userKey]
+	if p != nil && now.Before(p.ignoredUntil) {
+		return RateDropped, nil
+	}
+
+	chatBucket := rl.refill("chat:"+chat.String(), rl.config.Chat, now)
+	userBucket := rl.refill("user:"+userKey, rl.config.User, now)
+	var commandBucket *tokenBucket
+	if commandLimit != nil {
+		commandBucket = rl.refill("cmd:"+command+":"+userKey, *commandLimit, now)
+	}
+
+	// Chat yang ramai dibatasi tanpa menghukum user yang kebetulan mengirim
+	if !chatBucket.available() {
+		return RateDropped, nil
+	}
+	if !userBucket.available() || !commandBucket.available() {
+		verdict, p := rl.strike(userKey, now)
+		saved := *p
+		return verdict, &saved
+	}
+
+	// Token baru dipakai setelah semua bucket mengizinkan, sehingga user yang
+	// ditolak tidak menghabiskan jatah bersama chat
+	chatBucket.spend()
+	userBucket.spend()
+	commandBucket.spend()
+	return RateAllowed, nil
+}
+
+// Allow mengecek bucket name milik sender tanpa mencatat pelanggaran. Dipakai untuk
+// balasan yang tidak penting seperti saran command, sehingga melewati batas hanya
+// berarti balasan dilewati. User yang sedang diabaikan tidak pernah diizinkan.
+func (rl *RateLimiter) Allow(name string, sender types.JID, limit RateLimit) bool {
+	now := time.Now()
+	userKey := sender.ToNonAD().String()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.prune(now)
+
+	if p := rl.penalties[userKey]; p != nil && now.Before(p.ignoredUntil) {
+		return false
+	}
+
+	bucket := rl.refill(name+":"+userKey, limit, now)
+	if !bucket.available() {
+		return false
+	}
+	bucket.spend()
+	return true
+}
+
+// strike mencatat pelanggaran dan menentukan hukuman berikutnya; rl.mu harus dipegang pemanggil
+func (rl *RateLimiter) strike(userKey string, now time.Time) (RateLimitVerdict, *penalty) {
+	p := rl.penalties[userKey]
+	if p == nil {
+		p = &penalty{}
+		rl.penalties[userKey] = p
+	}
+
+	if now.Sub(p.lastStrike) > rl.config.StrikeWindow {
+		p.strikes = 0
+	}
+	p.strikes++
+	p.lastStrike = now
+
+	// Batas abaian dicek lebih dulu agar IgnoreAfter 1 langsung mengabaikan tanpa peringatan
+	verdict := RateDropped
+	switch {
+	case p.strikes >= rl.config.IgnoreAfter:
+		p.strikes = 0
+		p.ignores++
+		if rl.config.BlacklistAfter > 0 && p.ignores >= rl.config.BlacklistAfter {
+			p.ignores = 0
+			verdict = RateBlacklisted
+		} else {
+			p.ignoredUntil = now.Add(rl.ignoreDuration(p.ignores))
+			verdict = RateIgnored
+		}
+	case p.strikes == 1:
+		verdict = RateWarned
+	}
+
+	return verdict, p
+}
+
+// ignoreDuration menghitung lama abaian ke-n (berlipat dua setiap kali)
+func (rl *RateLimiter) ignoreDuration(ignores int) time.Duration {
+	if ignores < 1 {
+		ignores = 1
+	}
+	return rl.config.IgnoreDuration * time.Duration(1<<(ignores-1))
+}
+
+// IgnoredFor mengembalikan sisa waktu user diabaikan (0 jika tidak diabaikan)
+func (rl *RateLimiter) IgnoredFor(sender types.JID) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	p := rl.penalties[sender.ToNonAD().String()]
+	if p == nil {
+		return 0
+	}
+	if remaining := time.Until(p.ignoredUntil); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Reset menghapus status hukuman user, misalnya setelah dibuka blokirnya
+func (rl *RateLimiter) Reset(sender types.JID) error {
+	key := sender.ToNonAD().String()
+
+	rl.mu.Lock()
+	delete(rl.penalties, key)
+	rl.mu.Unlock()
+
+	if _, err := rl.db.Exec(`DELETE FROM furina_penalties WHERE jid = ?`, key); err != nil {
+		return fmt.Errorf("failed to reset penalty for %s: %v", key, err)
+	}
+	return nil
+}
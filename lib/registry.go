@@ -0,0 +1,395 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPluginExists dikembalikan saat mendaftarkan plugin dengan nama yang sudah dipakai
+	ErrPluginExists = errors.New("plugin already registered")
+
+	// ErrCommandConflict dikembalikan saat command atau alias sudah diklaim plugin lain
+	ErrCommandConflict = errors.New("command already registered")
+)
+
+// CommandSpec mendeskripsikan metadata satu command milik plugin
+type CommandSpec struct {
+	// Name adalah nama utama command (tanpa prefix)
+	Name string
+
+	// Aliases adalah nama alternatif yang memanggil command yang sama
+	Aliases []string
+
+	// Role adalah role minimum untuk menjalankan command (default RoleMember)
+	Role Role
+
+	// RateLimit adalah batas tambahan per user khusus command ini (nil = hanya batas global)
+	RateLimit *RateLimit
+
+	// Cooldown adalah jeda minimum antar pemakaian command (nil = tanpa cooldown)
+	Cooldown *Cooldown
+
+	// Timeout adalah batas waktu command berjalan (0 = timeout default plugin manager)
+	Timeout time.Duration
+
+	// Category adalah kelompok command di menu bantuan (kosong = DefaultCategory)
+	Category string
+
+	// Description adalah penjelasan singkat command untuk menu bantuan
+	Description string
+
+	// Usage adalah format argumen tanpa prefix dan nama command, misalnya "<nama> [global]".
+	// Jika kosong, format dibuat otomatis dari Args dan Flags.
+	Usage string
+
+	// Args adalah skema argumen posisional. Jika Args atau Flags diisi, argumen
+	// divalidasi sebelum plugin dijalankan dan hasilnya tersedia di Context.Params.
+	Args []ArgSpec
+
+	// Flags adalah skema flag --nama yang diterima command
+	Flags []FlagSpec
+
+	// Subcommands adalah pohon subcommand seperti "group kick". Jika diisi, command
+	// diteruskan ke Handler subcommand yang cocok, bukan ke Plugin.Handle.
+	Subcommands []Subcommand
+
+	// Examples adalah contoh pemakaian tanpa prefix, misalnya "plugin list"
+	Examples []string
+}
+
+// DefaultCategory adalah kategori untuk command yang tidak mendeklarasikan Category
+const DefaultCategory = "Lainnya"
+
+// CategoryName mengembalikan kategori command, atau DefaultCategory jika kosong
+func (s CommandSpec) CategoryName() string {
+	if s.Category == "" {
+		return DefaultCategory
+	}
+	return s.Category
+}
+
+// CommandSpecProvider bisa diimplementasikan plugin yang ingin mendeklarasikan
+// metadata command secara lengkap. Plugin yang tidak mengimplementasikannya
+// cukup memakai GetCommands tanpa alias.
+type CommandSpecProvider interface {
+	GetCommandSpecs() []CommandSpec
+}
+
+// Command adalah entri command yang sudah terdaftar di registry
+type Command struct {
+	CommandSpec
+
+	// Plugin adalah plugin pemilik command
+	Plugin Plugin
+}
+
+// CommandRegistry menyimpan indeks command → plugin yang dibangun saat registrasi
+type CommandRegistry struct {
+	mu            sync.RWMutex
+	caseSensitive bool
+	plugins       map[string]Plugin
+	order         []string
+	commands      map[string]*Command
+	list          []*Command
+	listeners     []*boundListener
+	nextOrder     int
+}
+
+// NewCommandRegistry membuat instance baru CommandRegistry
+func NewCommandRegistry(caseSensitive bool) *CommandRegistry {
+	return &CommandRegistry{
+		caseSensitive: caseSensitive,
+		plugins:       make(map[string]Plugin),
+		commands:      make(map[string]*Command),
+	}
+}
+
+// normalize menyesuaikan nama command dengan konfigurasi case sensitivity
+func (r *CommandRegistry) normalize(name string) string {
+	name = strings.TrimSpace(name)
+	if !r.caseSensitive {
+		name = strings.ToLower(name)
+	}
+	return name
+}
+
+// specsOf mengambil daftar CommandSpec dari plugin
+func specsOf(plugin Plugin) []CommandSpec {
+	if provider, ok := plugin.(CommandSpecProvider); ok {
+		return provider.GetCommandSpecs()
+	}
+
+	var specs []CommandSpec
+	for _, cmd := range plugin.GetCommands() {
+		specs = append(specs, CommandSpec{Name: cmd})
+	}
+	return specs
+}
+
+// Register mendaftarkan plugin beserta seluruh command dan aliasnya.
+// Registrasi bersifat atomik: jika ada konflik, tidak ada yang didaftarkan.
+func (r *CommandRegistry) Register(plugin Plugin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.plugins[plugin.GetName()]; exists {
+		return fmt.Errorf("%w: %s", ErrPluginExists, plugin.GetName())
+	}
+
+	claimed, entries, err := r.buildEntriesLocked(plugin)
+	if err != nil {
+		return err
+	}
+	listeners, err := listenersOf(plugin)
+	if err != nil {
+		return err
+	}
+
+	r.plugins[plugin.GetName()] = plugin
+	r.order = append(r.order, plugin.GetName())
+	r.insertLocked(claimed, entries)
+	r.insertListenersLocked(plugin, listeners, nil)
+
+	return nil
+}
+
+// Replace mengganti plugin terdaftar dengan nama yang sama, misalnya setelah
+// daftar command-nya berubah. Jika command baru bentrok, plugin lama tetap dipakai.
+func (r *CommandRegistry) Replace(plugin Plugin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := plugin.GetName()
+	if _, exists := r.plugins[name]; !exists {
+		return fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+	}
+
+	claimed, entries, err := r.buildEntriesLocked(plugin)
+	if err != nil {
+		return err
+	}
+	listeners, err := listenersOf(plugin)
+	if err != nil {
+		return err
+	}
+
+	// Command dan listener yang tetap ada mempertahankan posisinya agar menu
+	// bantuan dan urutan listener tidak berubah setiap kali plugin dimuat ulang
+	list := r.replacedListLocked(name, entries)
+	orders := make(map[string]int)
+	for _, listener := range r.listeners {
+		if listener.plugin.GetName() == name && listener.Name != "" {
+			orders[listener.Name] = listener.order
+		}
+	}
+
+	r.removeEntriesLocked(name)
+	r.plugins[name] = plugin
+	for key, entry := range claimed {
+		r.commands[key] = entry
+	}
+	r.list = list
+	r.insertListenersLocked(plugin, listeners, orders)
+
+	return nil
+}
+
+// replacedListLocked membuat daftar command baru dengan entri plugin name diganti
+// entries. Command dengan nama yang sama tetap di indeksnya, command baru
+// disisipkan setelah command terakhir plugin, dan command yang hilang dibuang.
+// Harus dipanggil dengan mu terkunci.
+func (r *CommandRegistry) replacedListLocked(name string, entries []*Command) []*Command {
+	replacements := make(map[string]*Command, len(entries))
+	for _, entry := range entries {
+		replacements[entry.Name] = entry
+	}
+
+	list := make([]*Command, 0, len(r.list)+len(entries))
+	insertAt := -1
+	for _, entry := range r.list {
+		if entry.Plugin.GetName() != name {
+			list = append(list, entry)
+			continue
+		}
+		if replacement, ok := replacements[entry.Name]; ok {
+			list = append(list, replacement)
+			delete(replacements, entry.Name)
+		}
+		insertAt = len(list)
+	}
+	if insertAt < 0 {
+		insertAt = len(list)
+	}
+
+	var added []*Command
+	for _, entry := range entries {
+		if _, ok := replacements[entry.Name]; ok {
+			added = append(added, entry)
+		}
+	}
+	return slices.Insert(list, insertAt, added...)
+}
+
+// buildEntriesLocked menyiapkan entri command plugin dan memeriksa konflik.
+// Command milik plugin dengan nama yang sama tidak dianggap konflik.
+// Harus dipanggil dengan mu terkunci.
+func (r *CommandRegistry) buildEntriesLocked(plugin Plugin) (map[string]*Command, []*Command, error) {
+	name := plugin.GetName()
+	if name == "" {
+		return nil, nil, fmt.Errorf("plugin name must not be empty")
+	}
+
+	var entries []*Command
+	claimed := make(map[string]*Command)
+
+	for _, spec := range specsOf(plugin) {
+		spec.Name = r.normalize(spec.Name)
+		spec.Aliases = append([]string(nil), spec.Aliases...)
+		if err := validateSubcommands(spec.Subcommands); err != nil {
+			return nil, nil, fmt.Errorf("plugin %s: command %s: %w", name, spec.Name, err)
+		}
+		entry := &Command{CommandSpec: spec, Plugin: plugin}
+
+		keys := append([]string{spec.Name}, spec.Aliases...)
+		for i, key := range keys {
+			key = r.normalize(key)
+			if key == "" {
+				return nil, nil, fmt.Errorf("plugin %s: command name must not be empty", name)
+			}
+			if owner, exists := r.commands[key]; exists && owner.Plugin.GetName() != name {
+				return nil, nil, fmt.Errorf("%w: %q (plugin %s) is already claimed by plugin %s", ErrCommandConflict, key, name, owner.Plugin.GetName())
+			}
+			if _, exists := claimed[key]; exists {
+				return nil, nil, fmt.Errorf("%w: %q is declared twice by plugin %s", ErrCommandConflict, key, name)
+			}
+			claimed[key] = entry
+			if i > 0 {
+				entry.Aliases[i-1] = key
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return claimed, entries, nil
+}
+
+// insertLocked memasukkan entri command ke indeks. Harus dipanggil dengan mu terkunci.
+func (r *CommandRegistry) insertLocked(claimed map[string]*Command, entries []*Command) {
+	for key, entry := range claimed {
+		r.commands[key] = entry
+	}
+	r.list = append(r.list, entries...)
+}
+
+// insertListenersLocked mendaftarkan listener plugin dan menjaga urutan prioritas.
+// Listener yang namanya ada di orders memakai urutan registrasi lamanya.
+// Harus dipanggil dengan mu terkunci.
+func (r *CommandRegistry) insertListenersLocked(plugin Plugin, listeners []Listener, orders map[string]int) {
+	for _, listener := range listeners {
+		order, ok := orders[listener.Name]
+		if !ok || listener.Name == "" {
+			order = r.nextOrder
+			r.nextOrder++
+		}
+		r.listeners = append(r.listeners, &boundListener{Listener: listener, plugin: plugin, order: order})
+	}
+	sortListeners(r.listeners)
+}
+
+// removeEntriesLocked menghapus semua command dan listener milik plugin dari indeks.
+// Harus dipanggil dengan mu terkunci.
+func (r *CommandRegistry) removeEntriesLocked(name string) {
+	listeners := r.listeners[:0]
+	for _, listener := range r.listeners {
+		if listener.plugin.GetName() != name {
+			listeners = append(listeners, listener)
+		}
+	}
+	r.listeners = listeners
+
+	for key, entry := range r.commands {
+		if entry.Plugin.GetName() == name {
+			delete(r.commands, key)
+		}
+	}
+
+	list := r.list[:0]
+	for _, entry := range r.list {
+		if entry.Plugin.GetName() != name {
+			list = append(list, entry)
+		}
+	}
+	r.list = list
+}
+
+// Unregister menghapus plugin beserta seluruh command-nya dari registry
+func (r *CommandRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.plugins[name]; !exists {
+		return false
+	}
+	delete(r.plugins, name)
+
+	for i, n := range r.order {
+		if n == name {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	r.removeEntriesLocked(name)
+
+	return true
+}
+
+// Lookup mencari command berdasarkan nama atau alias
+func (r *CommandRegistry) Lookup(name string) (*Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.commands[r.normalize(name)]
+	return entry, ok
+}
+
+// Commands mengembalikan semua command sesuai urutan registrasi
+func (r *CommandRegistry) Commands() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*Command(nil), r.list...)
+}
+
+// listenerList mengembalikan semua listener terurut berdasarkan prioritas
+func (r *CommandRegistry) listenerList() []*boundListener {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*boundListener(nil), r.listeners...)
+}
+
+// Plugin mencari plugin berdasarkan nama
+func (r *CommandRegistry) Plugin(name string) (Plugin, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plugin, ok := r.plugins[name]
+	return plugin, ok
+}
+
+// Plugins mengembalikan semua plugin sesuai urutan registrasi
+func (r *CommandRegistry) Plugins() []Plugin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plugins := make([]Plugin, 0, len(r.order))
+	for _, name := range r.order {
+		plugins = append(plugins, r.plugins[name])
+	}
+	return plugins
+}
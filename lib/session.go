@@ -2,6 +2,7 @@ package lib
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,7 @@ import (
 type SessionManager struct {
 	sessionsDir string
 	dbPath      string
+	db          *sql.DB
 	container   *sqlstore.Container
 	errorHandler *ErrorHandler
 }
@@ -67,15 +69,26 @@ func (sm *SessionManager) initializeDatabase() error {
 	// Setup logging dengan level ERROR untuk mengurangi spam
 	dbLog := waLog.Stdout("Database", "ERROR", false)
 	
-	// Buat database container
-	container, err := sqlstore.New(ctx, "sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on", sm.dbPath), dbLog)
+	// Buka database yang juga dipakai subsistem bot lain
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", sm.dbPath))
 	if err != nil {
+		if sm.errorHandler != nil {
+			sm.errorHandler.LogError(err, "SessionManager.initializeDatabase")
+		}
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Buat database container
+	container := sqlstore.NewWithDB(db, "sqlite3", dbLog)
+	if err := container.Upgrade(ctx); err != nil {
+		db.Close()
 		if sm.errorHandler != nil {
 			sm.errorHandler.LogError(err, "SessionManager.initializeDatabase")
 		}
 		return fmt.Errorf("failed to create database container: %v", err)
 	}
 
+	sm.db = db
 	sm.container = container
 	
 	if sm.errorHandler != nil {
@@ -85,6 +98,12 @@ func (sm *SessionManager) initializeDatabase() error {
 	return nil
 }
 
+// DB mengembalikan koneksi database SQLite bot di folder sesi.
+// Subsistem lain (izin, rate limit, dll) menyimpan tabelnya di sini.
+func (sm *SessionManager) DB() *sql.DB {
+	return sm.db
+}
+
 // GetFirstDevice mendapatkan device store pertama
 func (sm *SessionManager) GetFirstDevice(ctx context.Context) (*store.Device, error) {
 	device, err := sm.container.GetFirstDevice(ctx)
@@ -0,0 +1,218 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Subcommand adalah satu node di pohon subcommand, misalnya "kick" pada "!group kick".
+// Node bisa punya Handler, anak, atau keduanya.
+type Subcommand struct {
+	// Name adalah nama subcommand
+	Name string
+
+	// Aliases adalah nama alternatif subcommand
+	Aliases []string
+
+	// Description adalah penjelasan singkat subcommand untuk menu bantuan
+	Description string
+
+	// Role adalah role minimum untuk menjalankan subcommand dan semua anaknya.
+	// Role command induk tetap berlaku.
+	Role Role
+
+	// Usage adalah format argumen setelah nama subcommand (kosong = dari Args dan Flags)
+	Usage string
+
+	// Args adalah skema argumen posisional setelah nama subcommand
+	Args []ArgSpec
+
+	// Flags adalah skema flag --nama subcommand
+	Flags []FlagSpec
+
+	// Examples adalah contoh pemakaian tanpa prefix, misalnya "group kick @user"
+	Examples []string
+
+	// Handler dijalankan saat subcommand dipanggil. Node tanpa Handler hanya
+	// mengelompokkan anaknya dan membalas dengan daftar subcommand.
+	Handler Handler
+
+	// Subcommands adalah anak node ini
+	Subcommands []Subcommand
+}
+
+// UsageText mengembalikan format argumen subcommand
+func (s Subcommand) UsageText() string {
+	return usageText(s.Usage, s.Args, s.Flags, s.Subcommands)
+}
+
+// matches mengecek apakah nama cocok dengan nama atau alias subcommand
+func (s *Subcommand) matches(name string) bool {
+	if strings.EqualFold(s.Name, name) {
+		return true
+	}
+	for _, alias := range s.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// findSubcommand mencari anak yang cocok dengan nama
+func findSubcommand(subcommands []Subcommand, name string) *Subcommand {
+	for i := range subcommands {
+		if subcommands[i].matches(name) {
+			return &subcommands[i]
+		}
+	}
+	return nil
+}
+
+// FindSubcommand menelusuri pohon subcommand sesuai path dan mengembalikan node
+// terdalam yang cocok beserta jumlah elemen path yang terpakai
+func FindSubcommand(subcommands []Subcommand, path []string) (*Subcommand, int) {
+	var node *Subcommand
+	used := 0
+	for _, name := range path {
+		next := findSubcommand(subcommands, name)
+		if next == nil {
+			break
+		}
+		node = next
+		subcommands = next.Subcommands
+		used++
+	}
+	return node, used
+}
+
+// validateSubcommands memastikan setiap node punya nama unik di antara saudaranya
+// dan setiap daun punya Handler
+func validateSubcommands(subcommands []Subcommand) error {
+	seen := make(map[string]bool)
+	for _, sub := range subcommands {
+		if strings.TrimSpace(sub.Name) == "" {
+			return fmt.Errorf("subcommand name must not be empty")
+		}
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			key := strings.ToLower(name)
+			if seen[key] {
+				return fmt.Errorf("%w: subcommand %q is declared twice", ErrCommandConflict, name)
+			}
+			seen[key] = true
+		}
+		if sub.Handler == nil && len(sub.Subcommands) == 0 {
+			return fmt.Errorf("subcommand %s has no handler", sub.Name)
+		}
+		if err := validateSubcommands(sub.Subcommands); err != nil {
+			return fmt.Errorf("%s: %w", sub.Name, err)
+		}
+	}
+	return nil
+}
+
+// CanUse mengecek apakah pengirim punya role minimal yang dibutuhkan.
+// Tanpa permission manager semua role dianggap terpenuhi.
+func (c *Context) CanUse(required Role) bool {
+	if c.Manager == nil || c.Manager.Permissions() == nil || required <= RoleMember {
+		return true
+	}
+	return c.Role() >= required
+}
+
+// RenderSubcommands menyusun daftar subcommand yang boleh dipakai pengirim, termasuk
+// anak-anaknya. path adalah command dan subcommand induk tanpa prefix, misalnya "group settings".
+func RenderSubcommands(ctx *Context, path string, subcommands []Subcommand) string {
+	var text strings.Builder
+	renderSubcommands(&text, ctx, path, subcommands)
+	return text.String()
+}
+
+// renderSubcommands menulis daftar subcommand secara rekursif
+func renderSubcommands(text *strings.Builder, ctx *Context, path string, subcommands []Subcommand) {
+	for _, sub := range subcommands {
+		if !ctx.CanUse(sub.Role) {
+			continue
+		}
+
+		full := path + " " + sub.Name
+		if sub.Handler != nil {
+			line := ctx.Prefix + full
+			if usage := usageText(sub.Usage, sub.Args, sub.Flags, nil); usage != "" {
+				line += " " + usage
+			}
+			text.WriteString(fmt.Sprintf("• `%s`", line))
+			if sub.Description != "" {
+				text.WriteString(" - " + sub.Description)
+			}
+			text.WriteString("\n")
+		}
+		renderSubcommands(text, ctx, full, sub.Subcommands)
+	}
+}
+
+// subcommandGuard meneruskan command dengan pohon subcommand ke node yang cocok.
+// Subcommand yang tidak dikenal atau tidak lengkap dibalas dengan daftar subcommand.
+func (pm *PluginManager) subcommandGuard(next Handler) Handler {
+	return func(ctx *Context) error {
+		subcommands := ctx.Command.Subcommands
+		if len(subcommands) == 0 {
+			return next(ctx)
+		}
+
+		path := ctx.Command.Name
+		used := 0
+		for used < len(ctx.tokens) && len(subcommands) > 0 {
+			token := ctx.tokens[used]
+			sub := findSubcommand(subcommands, token.Value)
+			if sub == nil {
+				// Node yang punya Handler dan argumen menerima sisa token sebagai argumen
+				if current := ctx.Subcommand; current != nil && current.Handler != nil && (len(current.Args) > 0 || len(current.Flags) > 0) {
+					break
+				}
+				return pm.replySubcommands(ctx, path, subcommands, fmt.Sprintf("❌ Subcommand *%s* tidak dikenal.", token.Value))
+			}
+			if !ctx.CanUse(sub.Role) {
+				return ctx.Reply(DeniedMessage(sub.Role))
+			}
+
+			ctx.Subcommand = sub
+			ctx.SubcommandPath = append(ctx.SubcommandPath, sub.Name)
+			path += " " + sub.Name
+			subcommands = sub.Subcommands
+			used++
+		}
+
+		// Berhenti di node grup tanpa Handler: tampilkan daftar anaknya
+		if ctx.Subcommand == nil || ctx.Subcommand.Handler == nil {
+			return pm.replySubcommands(ctx, path, subcommands, "")
+		}
+
+		// Argumen subcommand dimulai setelah nama subcommand terakhir
+		rest := append([]argToken(nil), ctx.tokens[used:]...)
+		offset := len(ctx.argText)
+		if len(rest) > 0 {
+			offset = rest[0].Start
+		}
+		for i := range rest {
+			rest[i].Start -= offset
+		}
+		ctx.argText = ctx.argText[offset:]
+		ctx.tokens = rest
+		ctx.Args = ctx.Args[used:]
+
+		return next(ctx)
+	}
+}
+
+// replySubcommands membalas dengan daftar subcommand di bawah path
+func (pm *PluginManager) replySubcommands(ctx *Context, path string, subcommands []Subcommand, reason string) error {
+	var text strings.Builder
+	if reason != "" {
+		text.WriteString(reason + "\n\n")
+	}
+	text.WriteString(fmt.Sprintf("📝 *Pemakaian %s%s:*\n\n", ctx.Prefix, path))
+	text.WriteString(RenderSubcommands(ctx, path, subcommands))
+	text.WriteString(fmt.Sprintf("\n💡 Ketik `%smenu %s` untuk detail.", ctx.Prefix, ctx.Command.Name))
+	return ctx.Reply(text.String())
+}
@@ -0,0 +1,258 @@
+package lib
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SettingSuggestions adalah kunci ChatSettings untuk menyalakan atau mematikan
+// saran command di satu chat
+const SettingSuggestions = "suggestions"
+
+// maxSuggestions adalah jumlah maksimum saran yang ditampilkan
+const maxSuggestions = 3
+
+// suggestionRateLimit membatasi balasan saran per user agar salah ketik beruntun
+// tidak dibalas terus-menerus
+var suggestionRateLimit = RateLimit{Rate: 0.1, Burst: 3}
+
+// maxUnknownCommandLength membatasi panjang command tidak dikenal yang dicatat
+// agar teks acak setelah prefix tidak memenuhi tabel analitik
+const maxUnknownCommandLength = 32
+
+// normalizeUnknownCommand mengubah command tidak dikenal ke bentuk yang dicatat.
+// Hanya nama yang mirip command (huruf, angka, - dan _) dengan panjang wajar
+// yang diterima, sehingga teks acak setelah prefix tidak ikut dicatat.
+func normalizeUnknownCommand(command string) (string, bool) {
+	command = strings.ToLower(strings.TrimSpace(command))
+	if command == "" || utf8.RuneCountInString(command) > maxUnknownCommandLength {
+		return "", false
+	}
+	for _, r := range command {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", false
+		}
+	}
+	return command, true
+}
+
+// editDistance menghitung jarak edit antara dua string (per rune). Selain sisip,
+// hapus dan ganti, pertukaran dua huruf bersebelahan ("hlep" → "help") dihitung satu.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+// suggestionScore memberi skor kemiripan input dengan nama command.
+// Semakin kecil semakin mirip; -1 berarti tidak cukup mirip.
+func suggestionScore(input, name string) int {
+	// Satu huruf terlalu pendek untuk ditebak
+	if len([]rune(input)) < 2 {
+		return -1
+	}
+
+	// Input adalah awalan nama command, misalnya "men" untuk "menu"
+	if strings.HasPrefix(name, input) {
+		return 0
+	}
+
+	// Batas jarak edit mengikuti panjang input agar kata pendek tidak cocok dengan semuanya
+	limit := 1
+	if len([]rune(input)) > 4 {
+		limit = 2
+	}
+	if distance := editDistance(input, name); distance <= limit {
+		return distance
+	}
+	return -1
+}
+
+// SuggestCommands mencari command terdaftar yang mirip dengan input berdasarkan
+// jarak edit dan awalan nama. Hanya command yang boleh dijalankan pengirim yang disarankan.
+func (pm *PluginManager) SuggestCommands(ctx *Context, input string) []string {
+	input = strings.ToLower(input)
+
+	type candidate struct {
+		name  string
+		score int
+	}
+	var candidates []candidate
+
+	for _, cmd := range pm.AvailableCommands(ctx) {
+		best := candidate{score: -1}
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			score := suggestionScore(input, strings.ToLower(name))
+			if score >= 0 && (best.score < 0 || score < best.score) {
+				best = candidate{name: name, score: score}
+			}
+		}
+		if best.score >= 0 {
+			candidates = append(candidates, best)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score < candidates[j].score
+	})
+
+	var names []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// UnknownCommand adalah statistik satu command tidak dikenal
+type UnknownCommand struct {
+	Command  string
+	Count    int
+	LastSeen time.Time
+}
+
+// UnknownCommandStats mencatat command tidak dikenal yang diketik user untuk analitik,
+// misalnya untuk mengetahui command apa yang sering dicari tapi belum ada
+type UnknownCommandStats struct {
+	db *sql.DB
+}
+
+// NewUnknownCommandStats membuat instance baru UnknownCommandStats
+func NewUnknownCommandStats(db *sql.DB) (*UnknownCommandStats, error) {
+	us := &UnknownCommandStats{db: db}
+	if err := us.initializeTable(); err != nil {
+		return nil, err
+	}
+	return us, nil
+}
+
+// initializeTable membuat tabel statistik command tidak dikenal jika belum ada
+func (us *UnknownCommandStats) initializeTable() error {
+	_, err := us.db.Exec(`CREATE TABLE IF NOT EXISTS furina_unknown_commands (
+		command   TEXT PRIMARY KEY,
+		count     INTEGER NOT NULL,
+		last_seen INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create unknown command table: %v", err)
+	}
+	return nil
+}
+
+// Record menambah hitungan command tidak dikenal
+func (us *UnknownCommandStats) Record(command string) error {
+	_, err := us.db.Exec(`INSERT INTO furina_unknown_commands (command, count, last_seen) VALUES (?, 1, ?)
+		ON CONFLICT (command) DO UPDATE SET count = count + 1, last_seen = excluded.last_seen`,
+		command, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to record unknown command %s: %v", command, err)
+	}
+	return nil
+}
+
+// Top mengembalikan command tidak dikenal yang paling sering diketik
+func (us *UnknownCommandStats) Top(limit int) ([]UnknownCommand, error) {
+	rows, err := us.db.Query(`SELECT command, count, last_seen FROM furina_unknown_commands
+		ORDER BY count DESC, last_seen DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unknown commands: %v", err)
+	}
+	defer rows.Close()
+
+	var commands []UnknownCommand
+	for rows.Next() {
+		var command UnknownCommand
+		var lastSeen int64
+		if err := rows.Scan(&command.Command, &command.Count, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to read unknown command: %v", err)
+		}
+		command.LastSeen = time.UnixMilli(lastSeen)
+		commands = append(commands, command)
+	}
+	return commands, rows.Err()
+}
+
+// Reset menghapus semua statistik command tidak dikenal
+func (us *UnknownCommandStats) Reset() error {
+	if _, err := us.db.Exec(`DELETE FROM furina_unknown_commands`); err != nil {
+		return fmt.Errorf("failed to reset unknown commands: %v", err)
+	}
+	return nil
+}
+
+// SetUnknownCommandStats mengaktifkan pencatatan command tidak dikenal
+func (pm *PluginManager) SetUnknownCommandStats(stats *UnknownCommandStats) {
+	pm.unknownStats = stats
+}
+
+// UnknownCommandStats mengembalikan pencatat command tidak dikenal (bisa nil)
+func (pm *PluginManager) UnknownCommandStats() *UnknownCommandStats {
+	return pm.unknownStats
+}
+
+// handleUnknownCommand mencatat command yang tidak dikenal dan membalas dengan saran
+// command yang mirip. Pencatatan selalu berjalan; balasan saran dilewati jika saran
+// dimatikan di chat ini, pengirim diblokir atau terlalu sering salah ketik.
+func (pm *PluginManager) handleUnknownCommand(ctx *Context) error {
+	if pm.unknownStats != nil {
+		if command, ok := normalizeUnknownCommand(ctx.Invoked); ok {
+			if err := pm.unknownStats.Record(command); err != nil {
+				return err
+			}
+		}
+	}
+
+	if pm.chatSettings != nil && !pm.chatSettings.Bool(ctx.Chat, SettingSuggestions, true) {
+		return nil
+	}
+	if pm.permissions != nil && pm.permissions.IsBanned(ctx.Ctx, ctx.Event.Info.MessageSource) {
+		return nil
+	}
+
+	// Obrolan biasa seperti "!lol" juga sampai ke sini, jadi saran dibatasi lewat
+	// bucket sendiri yang tidak memberi hukuman. Owner dan admin bot tidak dibatasi.
+	if pm.rateLimiter != nil {
+		privileged := pm.permissions != nil && pm.permissions.IsPrivileged(ctx.Ctx, ctx.Event.Info.MessageSource)
+		if !privileged && !pm.rateLimiter.Allow("suggest", ctx.Sender, suggestionRateLimit) {
+			return nil
+		}
+	}
+
+	suggestions := pm.SuggestCommands(ctx, ctx.Invoked)
+	if len(suggestions) == 0 {
+		return nil
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("❓ Command *%s%s* tidak ditemukan. Mungkin maksudmu:\n\n", ctx.Prefix, ctx.Invoked))
+	for _, name := range suggestions {
+		text.WriteString(fmt.Sprintf("• `%s%s`\n", ctx.Prefix, name))
+	}
+	text.WriteString(fmt.Sprintf("\n💡 Ketik `%smenu` untuk melihat semua command.", ctx.Prefix))
+
+	return ctx.Reply(text.String())
+}
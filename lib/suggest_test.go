@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+// suggestPlugin adalah plugin minimal dengan spesifikasi command untuk pengujian saran
+type suggestPlugin struct {
+	specs []CommandSpec
+}
+
+func (p *suggestPlugin) GetName() string { return "suggest" }
+
+func (p *suggestPlugin) GetCommands() []string {
+	var names []string
+	for _, spec := range p.specs {
+		names = append(names, spec.Name)
+	}
+	return names
+}
+
+func (p *suggestPlugin) Handle(ctx *Context) error { return nil }
+
+func (p *suggestPlugin) GetDescription() string { return "plugin pengujian saran command" }
+
+func (p *suggestPlugin) GetCommandSpecs() []CommandSpec { return p.specs }
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "help", b: "help", want: 0},
+		{a: "", b: "menu", want: 4},
+		{a: "menu", b: "", want: 4},
+		{a: "mnu", b: "menu", want: 1},
+		{a: "menuu", b: "menu", want: 1},
+		{a: "mena", b: "menu", want: 1},
+		{a: "hlep", b: "help", want: 1},
+		{a: "ehlp", b: "help", want: 1},
+		{a: "stcker", b: "sticker", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "cafè", b: "cafe", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := editDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestionScore(t *testing.T) {
+	tests := []struct {
+		input, name string
+		want        int
+	}{
+		{input: "m", name: "menu", want: -1},
+		{input: "me", name: "menu", want: 0},
+		{input: "men", name: "menu", want: 0},
+		{input: "hlep", name: "help", want: 1},
+		{input: "pnig", name: "ping", want: 1},
+		{input: "pong", name: "ping", want: 1},
+		{input: "pxnx", name: "ping", want: -1},
+		{input: "stcker", name: "sticker", want: 1},
+		{input: "stckr", name: "sticker", want: 2},
+		{input: "stkr", name: "sticker", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+"/"+tt.name, func(t *testing.T) {
+			if got := suggestionScore(tt.input, tt.name); got != tt.want {
+				t.Errorf("suggestionScore(%q, %q) = %d, want %d", tt.input, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestCommands(t *testing.T) {
+	pm := NewPluginManager(nil, nil)
+	plugin := &suggestPlugin{specs: []CommandSpec{
+		{Name: "menu", Aliases: []string{"help"}},
+		{Name: "stock"},
+		{Name: "sticker", Aliases: []string{"s", "stiker"}},
+		{Name: "ping"},
+		{Name: "pin"},
+		{Name: "plugin"},
+		{Name: "playlist"},
+	}}
+	if err := pm.registry.Register(plugin); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	ctx := &Context{Manager: pm}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "salah ketik nama", input: "mneu", want: []string{"menu"}},
+		{name: "salah ketik alias", input: "hlep", want: []string{"help"}},
+		{name: "alias lebih mirip dari nama", input: "stikr", want: []string{"stiker"}},
+		{name: "huruf besar diabaikan", input: "MNEU", want: []string{"menu"}},
+		{name: "awalan nama command", input: "pi", want: []string{"ping", "pin"}},
+		{name: "awalan sebelum jarak edit", input: "stick", want: []string{"sticker", "stock"}},
+		{name: "satu huruf tidak ditebak", input: "x", want: nil},
+		{name: "tidak mirip", input: "xyzzy", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pm.SuggestCommands(ctx, tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuggestCommands(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeUnknownCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		ok      bool
+	}{
+		{command: "Mnue", want: "mnue", ok: true},
+		{command: " ping_2 ", want: "ping_2", ok: true},
+		{command: "auto-reply", want: "auto-reply", ok: true},
+		{command: "stikér", want: "stikér", ok: true},
+		{command: "", ok: false},
+		{command: "halo!", ok: false},
+		{command: "https://example.com", ok: false},
+		{command: "abcdefghijklmnopqrstuvwxyz012345", want: "abcdefghijklmnopqrstuvwxyz012345", ok: true},
+		{command: "abcdefghijklmnopqrstuvwxyz0123456", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, ok := normalizeUnknownCommand(tt.command)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("normalizeUnknownCommand(%q) = %q, %v, want %q, %v", tt.command, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package lib
+
+import (
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// QuotedMessage adalah pesan yang dibalas (di-reply) oleh pesan yang sedang diproses
+type QuotedMessage struct {
+	// ID adalah ID pesan yang dibalas
+	ID types.MessageID
+
+	// Sender adalah JID pengirim pesan yang dibalas (kosong jika tidak diketahui)
+	Sender types.JID
+
+	// Text adalah teks atau caption pesan yang dibalas
+	Text string
+
+	// Message adalah isi pesan yang dibalas
+	Message *waE2E.Message
+
+	// Media adalah media di pesan yang dibalas (gambar, video, audio, dokumen
+	// atau stiker), nil jika pesan yang dibalas bukan media
+	Media *Media
+}
+
+// MessageText mengambil teks dari pesan biasa, pesan teks dengan format, mention
+// atau reply (ExtendedTextMessage), caption gambar/video/dokumen, dan pesan yang diedit
+func MessageText(message *events.Message) string {
+	return ExtractText(message.Message)
+}
+
+// ExtractText mengambil teks atau caption dari isi pesan
+func ExtractText(message *waE2E.Message) string {
+	message = messageContent(message)
+
+	switch {
+	case message.GetConversation() != "":
+		return message.GetConversation()
+	case message.GetExtendedTextMessage() != nil:
+		return message.GetExtendedTextMessage().GetText()
+	case message.GetImageMessage() != nil:
+		return message.GetImageMessage().GetCaption()
+	case message.GetVideoMessage() != nil:
+		return message.GetVideoMessage().GetCaption()
+	case message.GetDocumentMessage() != nil:
+		return message.GetDocumentMessage().GetCaption()
+	default:
+		return ""
+	}
+}
+
+// messageContent mengambil isi pesan. Pesan yang diedit dikirim sebagai
+// ProtocolMessage berisi pesan versi baru.
+func messageContent(message *waE2E.Message) *waE2E.Message {
+	protocol := message.GetProtocolMessage()
+	if protocol.GetType() == waE2E.ProtocolMessage_MESSAGE_EDIT && protocol.GetEditedMessage() != nil {
+		return protocol.GetEditedMessage()
+	}
+	return message
+}
+
+// IsUpdateMessage mengecek apakah pesan hanya pembaruan untuk pesan lain (vote
+// polling, reaksi, atau pesan protokol seperti hapus pesan) dan bukan isi percakapan.
+// Pesan yang diedit tidak termasuk karena berisi teks baru.
+func IsUpdateMessage(message *waE2E.Message) bool {
+	protocol := message.GetProtocolMessage()
+	switch {
+	case message.GetPollUpdateMessage() != nil, message.GetReactionMessage() != nil:
+		return true
+	case protocol != nil:
+		return protocol.GetType() != waE2E.ProtocolMessage_MESSAGE_EDIT
+	default:
+		return false
+	}
+}
+
+// messageContextInfo mengambil ContextInfo (mention, reply) dari jenis pesan apa pun
+func messageContextInfo(message *waE2E.Message) *waE2E.ContextInfo {
+	message = messageContent(message)
+
+	switch {
+	case message.GetExtendedTextMessage() != nil:
+		return message.GetExtendedTextMessage().GetContextInfo()
+	case message.GetImageMessage() != nil:
+		return message.GetImageMessage().GetContextInfo()
+	case message.GetVideoMessage() != nil:
+		return message.GetVideoMessage().GetContextInfo()
+	case message.GetDocumentMessage() != nil:
+		return message.GetDocumentMessage().GetContextInfo()
+	case message.GetAudioMessage() != nil:
+		return message.GetAudioMessage().GetContextInfo()
+	case message.GetStickerMessage() != nil:
+		return message.GetStickerMessage().GetContextInfo()
+	case message.GetLocationMessage() != nil:
+		return message.GetLocationMessage().GetContextInfo()
+	case message.GetContactMessage() != nil:
+		return message.GetContactMessage().GetContextInfo()
+	case message.GetContactsArrayMessage() != nil:
+		return message.GetContactsArrayMessage().GetContextInfo()
+	case message.GetPollCreationMessage() != nil:
+		return message.GetPollCreationMessage().GetContextInfo()
+	default:
+		return nil
+	}
+}
+
+// quotedMessage mengambil pesan yang dibalas, nil jika pesan bukan balasan.
+// Media pesan yang dibalas diunduh lewat media manager.
+func quotedMessage(message *waE2E.Message, media *MediaManager) *QuotedMessage {
+	contextInfo := messageContextInfo(message)
+	if contextInfo.GetQuotedMessage() == nil {
+		return nil
+	}
+
+	quoted := &QuotedMessage{
+		ID:      contextInfo.GetStanzaID(),
+		Text:    ExtractText(contextInfo.GetQuotedMessage()),
+		Message: contextInfo.GetQuotedMessage(),
+		Media:   newMedia(media, contextInfo.GetQuotedMessage()),
+	}
+	if participant := contextInfo.GetParticipant(); participant != "" {
+		if jid, err := types.ParseJID(participant); err == nil {
+			quoted.Sender = jid
+		}
+	}
+	return quoted
+}
+
+// mentionedJIDs mengambil daftar JID yang di-mention di pesan
+func mentionedJIDs(message *waE2E.Message) []types.JID {
+	var jids []types.JID
+	for _, raw := range messageContextInfo(message).GetMentionedJID() {
+		jid, err := types.ParseJID(raw)
+		if err != nil {
+			continue
+		}
+		jids = append(jids, jid)
+	}
+	return jids
+}
@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultCommandTimeout adalah batas waktu default satu command berjalan
+const DefaultCommandTimeout = 30 * time.Second
+
+// timeoutNoticeDeadline adalah batas waktu mengirim pemberitahuan timeout ke user
+const timeoutNoticeDeadline = 10 * time.Second
+
+// commandGracePeriod adalah lama runCommand menunggu plugin berhenti setelah
+// ctx.Ctx berakhir, sebelum pesan berikutnya dari chat yang sama diproses
+const commandGracePeriod = 5 * time.Second
+
+// ErrCommandTimeout dikembalikan saat command melewati batas waktunya
+var ErrCommandTimeout = errors.New("command timed out")
+
+// SetCommandTimeout mengatur batas waktu default command yang tidak
+// mendeklarasikan CommandSpec.Timeout sendiri (0 = DefaultCommandTimeout)
+func (pm *PluginManager) SetCommandTimeout(timeout time.Duration) {
+	pm.commandTimeout = timeout
+}
+
+// CommandTimeout mengembalikan batas waktu yang berlaku untuk command
+func (pm *PluginManager) CommandTimeout(cmd *Command) time.Duration {
+	if cmd != nil && cmd.Timeout > 0 {
+		return cmd.Timeout
+	}
+	if pm.commandTimeout > 0 {
+		return pm.commandTimeout
+	}
+	return DefaultCommandTimeout
+}
+
+// runCommand menjalankan command dengan batas waktu. ctx.Ctx diganti dengan context
+// yang berakhir saat batas waktu habis atau context induk dibatalkan (misalnya saat
+// bot dihentikan). Setelah itu plugin diberi waktu commandGracePeriod untuk berhenti
+// agar urutan pesan per chat tetap terjaga, lalu user diberi tahu. Plugin yang tetap
+// berjalan setelahnya ditinggalkan; balasan lewat Context darinya dibuang karena
+// ctx.Ctx sudah berakhir.
+func (pm *PluginManager) runCommand(ctx *Context, handler Handler) error {
+	parent := ctx.Ctx
+	timeout := pm.CommandTimeout(ctx.Command)
+
+	runCtx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	ctx.Ctx = runCtx
+
+	// Salinan untuk pemberitahuan timeout, dibuat sebelum handler berjalan agar
+	// tidak bersinggungan dengan ctx yang masih dipakai handler
+	notice := *ctx
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic in plugin %s: %v", ctx.Command.Plugin.GetName(), r)
+			}
+		}()
+		done <- handler(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		// Plugin yang menghormati ctx.Ctx mengembalikan error deadline; tetap beri tahu user
+		if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+	case <-runCtx.Done():
+		grace := time.NewTimer(commandGracePeriod)
+		select {
+		case <-done:
+		case <-grace.C:
+			fmt.Printf("⚠️ Command %s tidak berhenti %v setelah batas waktunya, dilanjutkan tanpa menunggu\n", ctx.Command.Name, commandGracePeriod)
+		}
+		grace.Stop()
+	}
+
+	if err := parent.Err(); err != nil {
+		return fmt.Errorf("command %s cancelled: %v", ctx.Command.Name, err)
+	}
+
+	noticeCtx, cancelNotice := context.WithTimeout(context.WithoutCancel(parent), timeoutNoticeDeadline)
+	defer cancelNotice()
+	notice.Ctx = noticeCtx
+
+	err := fmt.Errorf("command %s: %w after %v", ctx.Command.Name, ErrCommandTimeout, timeout)
+	if replyErr := notice.Replyf("⌛ Command *%s%s* terlalu lama dan dihentikan setelah %s. Coba lagi nanti.", ctx.Prefix, ctx.Invoked, FormatDuration(timeout)); replyErr != nil {
+		return errors.Join(err, replyErr)
+	}
+	return err
+}
@@ -0,0 +1,562 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WasmConfig konfigurasi runtime plugin WebAssembly
+type WasmConfig struct {
+	// Dir adalah folder tempat file .wasm dicari
+	Dir string
+
+	// MemoryLimitPages adalah batas memori per instance (1 page = 64 KiB)
+	MemoryLimitPages uint32
+
+	// Timeout adalah batas waktu satu pemanggilan plugin
+	Timeout time.Duration
+
+	// PollInterval adalah jeda pengecekan perubahan file untuk hot reload
+	PollInterval time.Duration
+}
+
+// DefaultWasmConfig konfigurasi default
+func DefaultWasmConfig() *WasmConfig {
+	return &WasmConfig{
+		Dir:              "plugins/wasm",
+		MemoryLimitPages: 256,
+		Timeout:          5 * time.Second,
+		PollInterval:     2 * time.Second,
+	}
+}
+
+// wasmCallKey adalah kunci context untuk state pemanggilan yang sedang berjalan
+type wasmCallKey struct{}
+
+// wasmCall menyimpan state satu pemanggilan fungsi plugin WebAssembly
+type wasmCall struct {
+	input    []byte
+	ctx      *Context
+	manifest []byte
+	err      error
+}
+
+// wasmFile menyimpan informasi file untuk mendeteksi perubahan
+type wasmFile struct {
+	modTime time.Time
+	size    int64
+}
+
+// WasmLoader memuat plugin .wasm dari satu folder, mendaftarkannya ke
+// PluginManager, dan memuat ulang saat file berubah
+type WasmLoader struct {
+	config       *WasmConfig
+	manager      *PluginManager
+	errorHandler *ErrorHandler
+	runtime      wazero.Runtime
+
+	mu      sync.Mutex
+	plugins map[string]*WasmPlugin
+	files   map[string]wasmFile
+
+	watching bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewWasmLoader membuat runtime WebAssembly beserta host ABI "furina"
+func NewWasmLoader(ctx context.Context, config *WasmConfig, manager *PluginManager, errorHandler *ErrorHandler) (*WasmLoader, error) {
+	if config == nil {
+		config = DefaultWasmConfig()
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(config.MemoryLimitPages).
+		WithCloseOnContextDone(true)
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %v", err)
+	}
+
+	loader := &WasmLoader{
+		config:       config,
+		manager:      manager,
+		errorHandler: errorHandler,
+		runtime:      runtime,
+		plugins:      make(map[string]*WasmPlugin),
+		files:        make(map[string]wasmFile),
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	if err := loader.instantiateHostModule(ctx); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+
+	return loader, nil
+}
+
+// instantiateHostModule mendaftarkan fungsi host yang bisa diimpor plugin dari modul "furina":
+//
+//	input_len() i32              panjang JSON command yang sedang diproses
+//	input_read(ptr i32)          salin JSON command ke memori plugin
+//	reply(ptr i32, len i32)      balas pesan dengan teks
+//	send(ptr i32, len i32)       kirim teks ke chat tanpa quote
+//	react(ptr i32, len i32)      beri reaksi emoji ke pesan
+//	log(ptr i32, len i32)        tulis ke log bot
+//	set_manifest(ptr i32, len i32) laporkan daftar command dari fungsi describe
+func (l *WasmLoader) instantiateHostModule(ctx context.Context) error {
+	_, err := l.runtime.NewHostModuleBuilder("furina").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context) uint32 {
+		if call := wasmCallFrom(ctx); call != nil {
+			return uint32(len(call.input))
+		}
+		return 0
+	}).Export("input_len").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr uint32) {
+		if call := wasmCallFrom(ctx); call != nil && !m.Memory().Write(ptr, call.input) {
+			call.fail(fmt.Errorf("input_read: out of bounds write at %d", ptr))
+		}
+	}).Export("input_read").
+		NewFunctionBuilder().WithFunc(l.textFunc("reply", func(c *Context, text string) error {
+		return c.Reply(text)
+	})).Export("reply").
+		NewFunctionBuilder().WithFunc(l.textFunc("send", func(c *Context, text string) error {
+		return c.Send(text)
+	})).Export("send").
+		NewFunctionBuilder().WithFunc(l.textFunc("react", func(c *Context, emoji string) error {
+		return c.React(emoji)
+	})).Export("react").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, length uint32) {
+		if text, ok := m.Memory().Read(ptr, length); ok && l.errorHandler != nil {
+			l.errorHandler.LogInfo(string(text), "WasmPlugin")
+		}
+	}).Export("log").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, length uint32) {
+		call := wasmCallFrom(ctx)
+		if call == nil {
+			return
+		}
+		data, ok := m.Memory().Read(ptr, length)
+		if !ok {
+			call.fail(fmt.Errorf("set_manifest: out of bounds read at %d", ptr))
+			return
+		}
+		call.manifest = append([]byte(nil), data...)
+	}).Export("set_manifest").
+		Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate host module: %v", err)
+	}
+	return nil
+}
+
+// textFunc membuat fungsi host yang membaca teks dari memori plugin lalu
+// menjalankan aksi terhadap command yang sedang diproses
+func (l *WasmLoader) textFunc(name string, action func(c *Context, text string) error) func(ctx context.Context, m api.Module, ptr, length uint32) {
+	return func(ctx context.Context, m api.Module, ptr, length uint32) {
+		call := wasmCallFrom(ctx)
+		if call == nil || call.ctx == nil {
+			return
+		}
+		text, ok := m.Memory().Read(ptr, length)
+		if !ok {
+			call.fail(fmt.Errorf("%s: out of bounds read at %d", name, ptr))
+			return
+		}
+		if err := action(call.ctx, string(text)); err != nil {
+			call.fail(fmt.Errorf("%s: %v", name, err))
+		}
+	}
+}
+
+// wasmCallFrom mengambil state pemanggilan dari context
+func wasmCallFrom(ctx context.Context) *wasmCall {
+	call, _ := ctx.Value(wasmCallKey{}).(*wasmCall)
+	return call
+}
+
+// fail menyimpan error pertama yang terjadi selama pemanggilan
+func (c *wasmCall) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// Load memindai folder plugin dan mendaftarkan semua file .wasm yang ditemukan.
+// Error per file dikembalikan tanpa menghentikan pemuatan file lain.
+func (l *WasmLoader) Load(ctx context.Context) error {
+	if err := os.MkdirAll(l.config.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create wasm plugin directory: %v", err)
+	}
+	return l.scan(ctx)
+}
+
+// scan menyamakan plugin terdaftar dengan isi folder: memuat file baru,
+// memuat ulang file yang berubah, dan melepas file yang dihapus
+func (l *WasmLoader) scan(ctx context.Context) error {
+	paths, err := filepath.Glob(filepath.Join(l.config.Dir, "*.wasm"))
+	if err != nil {
+		return fmt.Errorf("failed to list wasm plugins: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		seen[path] = true
+	}
+
+	// Lepas plugin yang file-nya dihapus lebih dulu agar command-nya bisa dipakai file lain
+	for path, plugin := range l.plugins {
+		if seen[path] {
+			continue
+		}
+		l.manager.UnregisterPlugin(plugin.GetName())
+		plugin.close(ctx)
+		delete(l.plugins, path)
+		fmt.Printf("🧩 Plugin wasm %s dilepas\n", plugin.GetName())
+	}
+	for path := range l.files {
+		if !seen[path] {
+			delete(l.files, path)
+		}
+	}
+
+	var errs []error
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		current := wasmFile{modTime: info.ModTime(), size: info.Size()}
+		if previous, ok := l.files[path]; ok && previous == current {
+			continue
+		}
+		l.files[path] = current
+
+		if err := l.loadLocked(ctx, path); err != nil {
+			errs = append(errs, &PluginError{Plugin: wasmPluginName(path), Phase: "load", Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// wasmPluginName mengambil nama plugin dari nama file tanpa ekstensi
+func wasmPluginName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// loadLocked meng-compile file lalu mendaftarkan atau mengganti plugin.
+// Harus dipanggil dengan mu terkunci.
+func (l *WasmLoader) loadLocked(ctx context.Context, path string) error {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	compiled, err := l.runtime.CompileModule(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to compile: %v", err)
+	}
+
+	plugin, exists := l.plugins[path]
+	if !exists {
+		plugin = &WasmPlugin{name: wasmPluginName(path), loader: l}
+	}
+
+	manifest, err := plugin.describe(ctx, compiled)
+	if err != nil {
+		compiled.Close(ctx)
+		return err
+	}
+
+	module := &wasmModule{compiled: compiled}
+	previous, previousManifest := plugin.swap(module, manifest)
+
+	if exists {
+		err = l.manager.ReplacePlugin(plugin)
+	} else {
+		err = l.manager.RegisterPlugin(plugin)
+	}
+	if err != nil {
+		// Kembalikan versi lama agar plugin yang sudah jalan tidak rusak
+		plugin.swap(previous, previousManifest)
+		module.retire(ctx)
+		return err
+	}
+
+	// Modul lama baru ditutup setelah command yang masih memakainya selesai
+	previous.retire(ctx)
+	l.plugins[path] = plugin
+
+	if exists {
+		fmt.Printf("🧩 Plugin wasm %s dimuat ulang\n", plugin.GetName())
+	}
+	return nil
+}
+
+// Watch memantau folder plugin dan memuat ulang file yang berubah sampai Close dipanggil
+func (l *WasmLoader) Watch(ctx context.Context) {
+	l.watching = true
+	go func() {
+		defer close(l.stopped)
+
+		ticker := time.NewTicker(l.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := l.scan(ctx); err != nil && l.errorHandler != nil {
+					l.errorHandler.LogError(err, "WasmLoader.Watch")
+				}
+			case <-l.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close menghentikan hot reload dan menutup runtime WebAssembly
+func (l *WasmLoader) Close(ctx context.Context) error {
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+	}
+
+	if l.watching {
+		<-l.stopped
+	}
+
+	return l.runtime.Close(ctx)
+}
+
+// WasmPlugin adalah plugin yang dijalankan di sandbox WebAssembly.
+// Setiap command berjalan di instance baru dengan batas memori dan waktu.
+type WasmPlugin struct {
+	name   string
+	loader *WasmLoader
+
+	mu       sync.RWMutex
+	module   *wasmModule
+	manifest externalManifest
+}
+
+// wasmModule adalah modul ter-compile yang dipakai bersama oleh command yang
+// sedang berjalan. Modul yang sudah diganti atau dilepas baru ditutup setelah
+// pemakai terakhirnya selesai, karena wazero menolak instansiasi modul yang ditutup.
+type wasmModule struct {
+	compiled wazero.CompiledModule
+
+	mu      sync.Mutex
+	refs    int
+	retired bool
+}
+
+// acquire menandai modul sedang dipakai
+func (m *wasmModule) acquire() {
+	m.mu.Lock()
+	m.refs++
+	m.mu.Unlock()
+}
+
+// release melepas pemakaian modul dan menutupnya jika sudah diganti
+func (m *wasmModule) release(ctx context.Context) {
+	m.mu.Lock()
+	m.refs--
+	closeNow := m.retired && m.refs == 0
+	m.mu.Unlock()
+
+	if closeNow {
+		m.compiled.Close(ctx)
+	}
+}
+
+// retire menandai modul tidak dipakai lagi untuk command baru dan menutupnya
+// segera jika tidak ada command yang sedang berjalan
+func (m *wasmModule) retire(ctx context.Context) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.retired = true
+	closeNow := m.refs == 0
+	m.mu.Unlock()
+
+	if closeNow {
+		m.compiled.Close(ctx)
+	}
+}
+
+// Pastikan WasmPlugin mengimplementasikan interface Plugin
+var _ Plugin = (*WasmPlugin)(nil)
+
+// swap mengganti modul dan manifest, lalu mengembalikan yang lama
+func (p *WasmPlugin) swap(module *wasmModule, manifest externalManifest) (*wasmModule, externalManifest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous, previousManifest := p.module, p.manifest
+	p.module, p.manifest = module, manifest
+	return previous, previousManifest
+}
+
+// close melepas modul; modul ditutup setelah command yang sedang berjalan selesai
+func (p *WasmPlugin) close(ctx context.Context) {
+	p.mu.Lock()
+	module := p.module
+	p.module = nil
+	p.mu.Unlock()
+
+	module.retire(ctx)
+}
+
+// invoke membuat instance baru lalu memanggil fungsi yang diekspor plugin
+func (p *WasmPlugin) invoke(ctx context.Context, compiled wazero.CompiledModule, function string, call *wasmCall) error {
+	ctx, cancel := context.WithTimeout(ctx, p.loader.config.Timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, wasmCallKey{}, call)
+
+	moduleConfig := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithStdout(os.Stdout).
+		WithStderr(os.Stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+
+	module, err := p.loader.runtime.InstantiateModule(ctx, compiled, moduleConfig)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate: %v", err)
+	}
+	defer module.Close(context.Background())
+
+	fn := module.ExportedFunction(function)
+	if fn == nil {
+		return fmt.Errorf("module does not export %q", function)
+	}
+
+	if _, err := fn.Call(ctx); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s timed out after %v", function, p.loader.config.Timeout)
+		}
+		return fmt.Errorf("%s failed: %v", function, err)
+	}
+	return call.err
+}
+
+// describe memanggil fungsi describe untuk mendapatkan daftar command
+func (p *WasmPlugin) describe(ctx context.Context, compiled wazero.CompiledModule) (externalManifest, error) {
+	var manifest externalManifest
+
+	call := &wasmCall{}
+	if err := p.invoke(ctx, compiled, "describe", call); err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(call.manifest, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if len(manifest.Commands) == 0 {
+		return manifest, fmt.Errorf("wasm plugin %s declared no commands", p.name)
+	}
+	return manifest, nil
+}
+
+// GetName mengembalikan nama plugin (nama file tanpa .wasm)
+func (p *WasmPlugin) GetName() string {
+	return p.name
+}
+
+// GetCommands mengembalikan daftar command dari manifest plugin
+func (p *WasmPlugin) GetCommands() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var commands []string
+	for _, cmd := range p.manifest.Commands {
+		commands = append(commands, cmd.Name)
+	}
+	return commands
+}
+
+// GetCommandSpecs mengembalikan metadata command dari manifest plugin
+func (p *WasmPlugin) GetCommandSpecs() []CommandSpec {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var specs []CommandSpec
+	for _, cmd := range p.manifest.Commands {
+		specs = append(specs, cmd.spec())
+	}
+	return specs
+}
+
+// GetDescription mengembalikan deskripsi plugin
+func (p *WasmPlugin) GetDescription() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.manifest.Description != "" {
+		return p.manifest.Description
+	}
+	return fmt.Sprintf("Plugin WebAssembly (%s.wasm)", p.name)
+}
+
+// Handle menjalankan fungsi handle plugin di instance baru
+func (p *WasmPlugin) Handle(ctx *Context) error {
+	// acquire dilakukan sebelum kunci dilepas agar modul tidak ditutup oleh
+	// reload di antara pengambilan dan pemakaiannya
+	p.mu.RLock()
+	module := p.module
+	if module != nil {
+		module.acquire()
+	}
+	p.mu.RUnlock()
+
+	if module == nil {
+		return fmt.Errorf("%w: %s", ErrPluginUnavailable, p.name)
+	}
+	defer module.release(context.Background())
+
+	input, err := json.Marshal(externalInvocation{
+		Command:   ctx.Command.Name,
+		Invoked:   ctx.Invoked,
+		Args:      ctx.Args,
+		Text:      ctx.RawText,
+		Prefix:    ctx.Prefix,
+		Sender:    ctx.Sender.String(),
+		Chat:      ctx.Chat.String(),
+		IsGroup:   ctx.IsGroup,
+		MessageID: ctx.Event.Info.ID,
+		PushName:  ctx.Event.Info.PushName,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := p.invoke(ctx.Ctx, module.compiled, "handle", &wasmCall{input: input, ctx: ctx}); err != nil {
+		return fmt.Errorf("wasm plugin %s: %v", p.name, err)
+	}
+	return nil
+}
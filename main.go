@@ -3,14 +3,18 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"furina-bot/lib"
+	"furina-bot/plugins/admin"
 	"furina-bot/plugins/general"
+	"furina-bot/plugins/media"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types/events"
@@ -24,6 +28,8 @@ var (
 	errorHandler  *lib.ErrorHandler
 	sessionManager *lib.SessionManager
 	commandParser *lib.CommandParser
+	eventBus      *lib.EventBus
+	dispatcher    *lib.Dispatcher
 )
 
 func main() {
@@ -74,16 +80,150 @@ func main() {
 	client := whatsmeow.NewClient(deviceStore, clientLog)
 
 	// Inisialisasi command parser
-	commandParser = lib.NewCommandParser(lib.DefaultCommandConfig())
+	commandConfig := lib.DefaultCommandConfig()
+	commandParser = lib.NewCommandParser(commandConfig)
 	fmt.Println("✅ Command parser berhasil diinisialisasi")
 
 	// Inisialisasi plugin manager
-	pluginManager = lib.NewPluginManager(client)
+	pluginManager = lib.NewPluginManager(client, commandConfig)
+	pluginManager.Use(lib.RecoverMiddleware(), lib.LoggingMiddleware(errorHandler))
+
+	// Inisialisasi event bus agar plugin bisa menerima event selain pesan
+	eventBus = lib.NewEventBus(errorHandler)
+	pluginManager.SetEventBus(eventBus)
+
+	// Inisialisasi sistem izin, owner diambil dari FURINA_OWNERS (pisahkan dengan koma)
+	permissionConfig := lib.DefaultPermissionConfig()
+	if owners := os.Getenv("FURINA_OWNERS"); owners != "" {
+		permissionConfig.Owners = strings.Split(owners, ",")
+	}
+	permissionManager, err := lib.NewPermissionManager(client, sessionManager.DB(), permissionConfig)
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.permissionManager")
+		}
+		panic(fmt.Errorf("failed to initialize permission manager: %v", err))
+	}
+	pluginManager.SetPermissionManager(permissionManager)
+
+	// Daftar admin grup mungkin berubah, buang cache izin grup tersebut
+	lib.Subscribe(eventBus.Scope("permissions"), func(evt *events.GroupInfo) error {
+		permissionManager.InvalidateGroup(evt.JID)
+		return nil
+	})
+	fmt.Println("✅ Permission manager berhasil diinisialisasi")
+
+	// Inisialisasi rate limiter anti-spam
+	rateLimiter, err := lib.NewRateLimiter(sessionManager.DB(), lib.DefaultRateLimitConfig())
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.rateLimiter")
+		}
+		panic(fmt.Errorf("failed to initialize rate limiter: %v", err))
+	}
+	pluginManager.SetRateLimiter(rateLimiter)
+	fmt.Println("✅ Rate limiter berhasil diinisialisasi")
+
+	// Inisialisasi cooldown command
+	cooldownManager, err := lib.NewCooldownManager(sessionManager.DB())
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.cooldownManager")
+		}
+		panic(fmt.Errorf("failed to initialize cooldown manager: %v", err))
+	}
+	pluginManager.SetCooldownManager(cooldownManager)
+
+	// Inisialisasi status aktif/nonaktif plugin
+	pluginState, err := lib.NewPluginStateStore(sessionManager.DB())
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.pluginState")
+		}
+		panic(fmt.Errorf("failed to initialize plugin state: %v", err))
+	}
+	pluginManager.SetPluginStateStore(pluginState)
+
+	// Inisialisasi pengaturan per chat
+	chatSettings, err := lib.NewChatSettings(sessionManager.DB())
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.chatSettings")
+		}
+		panic(fmt.Errorf("failed to initialize chat settings: %v", err))
+	}
+	pluginManager.SetChatSettings(chatSettings)
+
+	// Inisialisasi statistik command tidak dikenal
+	unknownStats, err := lib.NewUnknownCommandStats(sessionManager.DB())
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.unknownStats")
+		}
+		panic(fmt.Errorf("failed to initialize unknown command stats: %v", err))
+	}
+	pluginManager.SetUnknownCommandStats(unknownStats)
+
+	// Inisialisasi percakapan bertahap (flow) yang tersimpan di database
+	conversations, err := lib.NewConversationManager(client, sessionManager.DB())
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.conversations")
+		}
+		panic(fmt.Errorf("failed to initialize conversation manager: %v", err))
+	}
+	pluginManager.SetConversationManager(conversations)
+
+	// Inisialisasi download media dengan cache file sementara
+	mediaManager, err := lib.NewMediaManager(client, lib.DefaultMediaConfig())
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.mediaManager")
+		}
+		panic(fmt.Errorf("failed to initialize media manager: %v", err))
+	}
+	pluginManager.SetMediaManager(mediaManager)
 	
 	// Daftarkan plugin
-	registerPlugins()
+	if err := registerPlugins(ctx); err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.registerPlugins")
+		}
+		panic(fmt.Errorf("failed to register plugins: %v", err))
+	}
 	fmt.Println("✅ Plugin manager berhasil diinisialisasi")
 
+	// Muat plugin WebAssembly dari plugins/wasm dengan hot reload
+	wasmLoader, err := lib.NewWasmLoader(ctx, lib.DefaultWasmConfig(), pluginManager, errorHandler)
+	if err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.wasmLoader")
+		}
+		panic(fmt.Errorf("failed to initialize wasm runtime: %v", err))
+	}
+	if err := wasmLoader.Load(ctx); err != nil {
+		reportPluginErrors(err, "main.wasmLoader")
+	}
+	wasmLoader.Watch(ctx)
+
+	// Jalankan lifecycle plugin: Init lalu Start
+	if err := pluginManager.InitPlugins(ctx); err != nil {
+		reportPluginErrors(err, "main.initPlugins")
+	}
+	if err := pluginManager.StartPlugins(ctx); err != nil {
+		reportPluginErrors(err, "main.startPlugins")
+	}
+	reportPluginHealth(ctx)
+
+	// Jalankan dispatcher agar pesan dari chat berbeda diproses paralel.
+	// dispatchCtx dibatalkan saat bot dihentikan untuk membatalkan semua command.
+	dispatchCtx, cancelDispatch := context.WithCancel(context.Background())
+	dispatcher = lib.NewDispatcher(dispatchCtx, lib.DefaultDispatcherConfig(), pluginManager.HandleMessage, errorHandler)
+	pluginManager.SetDispatcher(dispatcher)
+
+	// Akhiri percakapan yang tidak dijawab sampai batas waktunya
+	conversations.Watch(dispatchCtx, errorHandler)
+
 	// Add event handler
 	client.AddEventHandler(eventHandler)
 
@@ -141,27 +281,113 @@ func main() {
 	<-c
 
 	fmt.Println("\nMenghentikan bot...")
+
+	// Selesaikan pesan yang masih mengantre terlebih dahulu. Command yang sedang
+	// berjalan baru dibatalkan jika antrean belum habis sampai batas waktu.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	drainCtx, cancelDrain := context.WithTimeout(shutdownCtx, 20*time.Second)
+	if err := dispatcher.Shutdown(drainCtx); err != nil {
+		if errorHandler != nil {
+			errorHandler.LogError(err, "main.dispatcher")
+		}
+		cancelDispatch()
+		if err := dispatcher.Shutdown(shutdownCtx); err != nil && errorHandler != nil {
+			errorHandler.LogError(err, "main.dispatcher")
+		}
+	}
+	cancelDrain()
+	cancelDispatch()
+
+	// Hentikan plugin sebelum memutus koneksi
+	if err := pluginManager.StopPlugins(shutdownCtx); err != nil {
+		reportPluginErrors(err, "main.stopPlugins")
+	}
+	if err := wasmLoader.Close(shutdownCtx); err != nil && errorHandler != nil {
+		errorHandler.LogError(err, "main.wasmLoader")
+	}
+	if err := mediaManager.Close(); err != nil && errorHandler != nil {
+		errorHandler.LogError(err, "main.mediaManager")
+	}
+	cancel()
 	
 	client.Disconnect()
 	fmt.Println("👋 Bot berhasil dihentikan")
 }
 
+// reportPluginErrors menampilkan dan mencatat error lifecycle, satu baris per plugin
+func reportPluginErrors(err error, source string) {
+	if errorHandler != nil {
+		errorHandler.LogError(err, source)
+		return
+	}
+	fmt.Printf("⚠️ %s:\n%v\n", source, err)
+}
+
+// reportPluginHealth menjalankan health check semua plugin dan mencatat yang bermasalah
+func reportPluginHealth(ctx context.Context) {
+	results := pluginManager.HealthCheck(ctx)
+
+	var errs []error
+	for _, plugin := range pluginManager.GetPlugins() {
+		if err := results[plugin.GetName()]; err != nil {
+			errs = append(errs, &lib.PluginError{Plugin: plugin.GetName(), Phase: "health check", Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		reportPluginErrors(errors.Join(errs...), "main.healthCheck")
+	}
+}
+
 // registerPlugins mendaftarkan semua plugin yang tersedia
-func registerPlugins() {
+func registerPlugins(ctx context.Context) error {
+	plugins := []lib.Plugin{
+		// Plugin dari folder general
+		general.NewPingPlugin(),
+		general.NewHelpPlugin(),
+		general.NewPollPlugin(pluginManager, sessionManager.DB(), errorHandler),
+
+		// Plugin dari folder admin
+		admin.NewPluginAdminPlugin(),
+		admin.NewSettingsPlugin(),
+
+		// Plugin dari folder media
+		media.NewStickerPlugin(),
+	}
+
+	// Jalankan plugin eksternal (proses terpisah lewat JSON-RPC)
+	externalConfigs, err := lib.LoadExternalPluginConfigs("plugins/external/plugins.json")
+	if err != nil {
+		return err
+	}
+	var externalPlugins []*lib.ExternalPlugin
+	for _, config := range externalConfigs {
+		externalPlugin := lib.NewExternalPlugin(config, pluginManager, errorHandler)
+		if err := externalPlugin.Launch(ctx); err != nil {
+			// Plugin eksternal yang gagal tidak menghentikan bot; supervisor terus
+			// mencoba menjalankannya dengan backoff
+			fmt.Printf("⚠️ Plugin eksternal %s gagal dijalankan, akan dicoba lagi: %v\n", config.Name, err)
+		}
+		externalPlugins = append(externalPlugins, externalPlugin)
+		plugins = append(plugins, externalPlugin)
+	}
+
 	var registeredPlugins []string
-	
-	// Daftarkan plugin ping dari folder general
-	pingPlugin := general.NewPingPlugin()
-	pluginManager.RegisterPlugin(pingPlugin)
-	registeredPlugins = append(registeredPlugins, pingPlugin.GetName())
-	
-	// Daftarkan plugin help
-	helpPlugin := general.NewHelpPlugin()
-	pluginManager.RegisterPlugin(helpPlugin)
-	registeredPlugins = append(registeredPlugins, helpPlugin.GetName())
+	for _, plugin := range plugins {
+		if err := pluginManager.RegisterPlugin(plugin); err != nil {
+			// Hentikan proses plugin eksternal agar tidak tertinggal
+			stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			for _, externalPlugin := range externalPlugins {
+				externalPlugin.Stop(stopCtx)
+			}
+			cancel()
+			return err
+		}
+		registeredPlugins = append(registeredPlugins, plugin.GetName())
+	}
 	
 	// Tampilkan plugins terdaftar dalam satu baris
 	fmt.Printf("📦 Plugin terdaftar: %s\n", strings.Join(registeredPlugins, ", "))
+	return nil
 }
 
 func eventHandler(evt interface{}) {
@@ -172,11 +398,17 @@ func eventHandler(evt interface{}) {
 		}
 	}()
 
+	// Teruskan semua event ke subscriber plugin
+	eventBus.Publish(evt)
+
 	switch v := evt.(type) {
 	case *events.Message:
 		// Handle incoming messages
-		if !v.Info.IsFromMe && v.Message.GetConversation() != "" {
-			messageText := v.Message.GetConversation()
+		if v.Info.IsFromMe {
+			break
+		}
+
+		if messageText := lib.MessageText(v); messageText != "" {
 			senderJID := v.Info.Sender
 			
 			fmt.Printf("📨 Pesan dari %s: %s\n", senderJID, messageText)
@@ -185,16 +417,18 @@ func eventHandler(evt interface{}) {
 			if errorHandler != nil {
 				errorHandler.LogInfo(fmt.Sprintf("Message from %s: %s", senderJID, messageText), "eventHandler")
 			}
-			
-			// Cek apakah pesan adalah command
-			if commandParser.IsCommand(messageText) {
-				// Teruskan ke plugin manager
-				if err := pluginManager.HandleMessage(v); err != nil {
-					fmt.Printf("❌ Error handling command: %v\n", err)
-					if errorHandler != nil {
-						errorHandler.LogError(err, "eventHandler.pluginManager")
-					}
-				}
+		} else if v.Info.MediaType != "" {
+			fmt.Printf("📎 Media %s dari %s\n", v.Info.MediaType, v.Info.Sender)
+		} else if v.Message.GetPollUpdateMessage() != nil {
+			fmt.Printf("🗳️ Vote polling dari %s\n", v.Info.Sender)
+		}
+
+		// Teruskan semua pesan ke plugin manager lewat dispatcher: listener menerima
+		// pesan biasa, command diteruskan ke plugin yang sesuai
+		if err := dispatcher.Submit(v); err != nil {
+			fmt.Printf("⚠️ Pesan dari %s dibuang: %v\n", v.Info.Sender, err)
+			if errorHandler != nil {
+				errorHandler.LogError(err, "eventHandler.dispatcher")
 			}
 		}
 	case *events.Receipt:
@@ -206,7 +440,7 @@ func eventHandler(evt interface{}) {
 		fmt.Println("\n✅ Bot WhatsApp Furina berhasil terhubung!")
 		fmt.Println("💾 Sesi tersimpan di: lib/sessions/")
 		fmt.Println("🤖 Bot siap menerima pesan")
-		fmt.Println("🎯 Prefix command: ! (contoh: !ping)")
+		fmt.Printf("🎯 Prefix command: %s (contoh: %sping)\n", commandParser.Prefix(), commandParser.Prefix())
 		fmt.Println("⚡ Tekan Ctrl+C untuk menghentikan bot")
 		
 		if errorHandler != nil {
@@ -0,0 +1,214 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"furina-bot/lib"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// PluginAdminPlugin adalah plugin untuk mengelola plugin lain saat bot berjalan
+type PluginAdminPlugin struct{}
+
+// Pastikan PluginAdminPlugin mengimplementasikan interface Plugin
+var _ lib.Plugin = (*PluginAdminPlugin)(nil)
+
+// NewPluginAdminPlugin membuat instance baru PluginAdminPlugin
+func NewPluginAdminPlugin() *PluginAdminPlugin {
+	return &PluginAdminPlugin{}
+}
+
+// GetName mengembalikan nama plugin
+func (p *PluginAdminPlugin) GetName() string {
+	return "plugin"
+}
+
+// GetCommands mengembalikan daftar command yang didukung
+func (p *PluginAdminPlugin) GetCommands() []string {
+	return []string{"plugin"}
+}
+
+// GetCommandSpecs mengembalikan metadata command plugin beserta pohon subcommand-nya
+func (p *PluginAdminPlugin) GetCommandSpecs() []lib.CommandSpec {
+	target := lib.ArgSpec{Name: "nama", Description: "Nama plugin, lihat daftar di plugin list"}
+	scope := lib.ArgSpec{Name: "global", Optional: true, Description: "Tulis *global* untuk semua chat (khusus owner)"}
+
+	return []lib.CommandSpec{
+		{
+			Name:        "plugin",
+			Aliases:     []string{"plugins"},
+			Role:        lib.RoleGroupAdmin,
+			Category:    "Admin",
+			Description: "Lihat, aktifkan, nonaktifkan dan jalankan ulang plugin",
+			Examples:    []string{"plugin list", "plugin disable ping", "plugin enable ping global", "plugin health"},
+			Subcommands: []lib.Subcommand{
+				{
+					Name:        "list",
+					Description: "Lihat semua plugin",
+					Handler:     p.list,
+				},
+				{
+					Name:        "enable",
+					Aliases:     []string{"on"},
+					Description: "Aktifkan plugin di chat ini",
+					Args:        []lib.ArgSpec{target, scope},
+					Handler:     func(ctx *lib.Context) error { return p.setEnabled(ctx, true) },
+				},
+				{
+					Name:        "disable",
+					Aliases:     []string{"off"},
+					Description: "Nonaktifkan plugin di chat ini",
+					Args:        []lib.ArgSpec{target, scope},
+					Handler:     func(ctx *lib.Context) error { return p.setEnabled(ctx, false) },
+				},
+				{
+					Name:        "restart",
+					Description: "Jalankan ulang plugin eksternal",
+					Role:        lib.RoleOwner,
+					Args:        []lib.ArgSpec{target},
+					Handler:     p.restart,
+				},
+				{
+					Name:        "health",
+					Description: "Periksa kondisi plugin yang mendukung health check",
+					Role:        lib.RoleOwner,
+					Handler:     p.health,
+				},
+			},
+		},
+	}
+}
+
+// GetDescription mengembalikan deskripsi plugin
+func (p *PluginAdminPlugin) GetDescription() string {
+	return "Plugin untuk melihat, mengaktifkan dan menonaktifkan plugin"
+}
+
+// IsEssential menandai plugin ini tidak boleh dinonaktifkan
+func (p *PluginAdminPlugin) IsEssential() bool {
+	return true
+}
+
+// Handle tidak dipakai karena semua command plugin diteruskan ke subcommand
+func (p *PluginAdminPlugin) Handle(ctx *lib.Context) error {
+	return nil
+}
+
+// list menampilkan semua plugin beserta statusnya di chat ini
+func (p *PluginAdminPlugin) list(ctx *lib.Context) error {
+	var text strings.Builder
+	text.WriteString("📦 *Daftar Plugin:*\n\n")
+
+	state := ctx.Manager.PluginStateStore()
+	for _, plugin := range ctx.Manager.GetPlugins() {
+		status := "✅ aktif"
+		if state != nil {
+			if state.IsDisabledGlobally(plugin.GetName()) {
+				status = "⛔ nonaktif (global)"
+			} else if state.IsDisabledInChat(plugin.GetName(), ctx.Chat) {
+				status = "🔕 nonaktif di chat ini"
+			}
+		}
+		text.WriteString(fmt.Sprintf("• *%s* - %s\n  %s\n", plugin.GetName(), status, plugin.GetDescription()))
+	}
+
+	return ctx.Reply(text.String())
+}
+
+// pluginName mengembalikan nama plugin terdaftar yang cocok dengan input tanpa
+// membedakan huruf besar. Nama plugin eksternal dan wasm bisa mengandung huruf besar,
+// jadi nama terdaftar dipakai apa adanya; input dikembalikan jika tidak ada yang cocok.
+func pluginName(ctx *lib.Context, input string) string {
+	for _, plugin := range ctx.Manager.GetPlugins() {
+		if plugin.GetName() == input {
+			return input
+		}
+	}
+	for _, plugin := range ctx.Manager.GetPlugins() {
+		if strings.EqualFold(plugin.GetName(), input) {
+			return plugin.GetName()
+		}
+	}
+	return input
+}
+
+// setEnabled mengaktifkan atau menonaktifkan plugin di chat ini atau secara global
+func (p *PluginAdminPlugin) setEnabled(ctx *lib.Context, enabled bool) error {
+	name := pluginName(ctx, ctx.Params.String("nama"))
+	global := ctx.Params.Has("global")
+	if global && !strings.EqualFold(ctx.Params.String("global"), "global") {
+		return ctx.ReplyUsage(fmt.Sprintf("Cakupan *%s* tidak dikenal, gunakan *global*.", ctx.Params.String("global")))
+	}
+
+	chat := ctx.Chat
+	scope := "di chat ini"
+	if global {
+		if ctx.Role() < lib.RoleOwner {
+			return ctx.Reply(lib.DeniedMessage(lib.RoleOwner))
+		}
+		chat = types.EmptyJID
+		scope = "secara global"
+	}
+
+	var err error
+	if enabled {
+		err = ctx.Manager.EnablePlugin(name, chat)
+	} else {
+		err = ctx.Manager.DisablePlugin(name, chat)
+	}
+
+	switch {
+	case errors.Is(err, lib.ErrPluginNotFound):
+		return ctx.Replyf("❌ Plugin *%s* tidak ditemukan. Lihat %splugin list.", name, ctx.Prefix)
+	case errors.Is(err, lib.ErrPluginEssential):
+		return ctx.Replyf("❌ Plugin *%s* tidak bisa dinonaktifkan.", name)
+	case err != nil:
+		return err
+	}
+
+	if enabled {
+		return ctx.Replyf("✅ Plugin *%s* diaktifkan %s.", name, scope)
+	}
+	return ctx.Replyf("⛔ Plugin *%s* dinonaktifkan %s.", name, scope)
+}
+
+// restart menjalankan ulang plugin yang mendukungnya, misalnya plugin eksternal
+func (p *PluginAdminPlugin) restart(ctx *lib.Context) error {
+	name := pluginName(ctx, ctx.Params.String("nama"))
+	err := ctx.Manager.RestartPlugin(ctx.Ctx, name)
+	switch {
+	case errors.Is(err, lib.ErrPluginNotFound):
+		return ctx.Replyf("❌ Plugin *%s* tidak ditemukan. Lihat %splugin list.", name, ctx.Prefix)
+	case err != nil:
+		return ctx.Replyf("❌ Gagal menjalankan ulang plugin *%s*: %v", name, err)
+	}
+
+	return ctx.Replyf("🔄 Plugin *%s* sedang dijalankan ulang.", name)
+}
+
+// health menampilkan hasil health check semua plugin yang mendukungnya
+func (p *PluginAdminPlugin) health(ctx *lib.Context) error {
+	results := ctx.Manager.HealthCheck(ctx.Ctx)
+	if len(results) == 0 {
+		return ctx.Reply("ℹ️ Tidak ada plugin yang mendukung health check.")
+	}
+
+	var text strings.Builder
+	text.WriteString("🩺 *Kondisi Plugin:*\n\n")
+	for _, plugin := range ctx.Manager.GetPlugins() {
+		err, ok := results[plugin.GetName()]
+		if !ok {
+			continue
+		}
+		if err != nil {
+			text.WriteString(fmt.Sprintf("• *%s* - ❌ %v\n", plugin.GetName(), err))
+		} else {
+			text.WriteString(fmt.Sprintf("• *%s* - ✅ sehat\n", plugin.GetName()))
+		}
+	}
+
+	return ctx.Reply(text.String())
+}
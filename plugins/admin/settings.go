@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"furina-bot/lib"
+)
+
+// SettingsPlugin adalah plugin untuk mengatur perilaku bot per chat
+type SettingsPlugin struct{}
+
+// Pastikan SettingsPlugin mengimplementasikan interface Plugin
+var _ lib.Plugin = (*SettingsPlugin)(nil)
+
+// NewSettingsPlugin membuat instance baru SettingsPlugin
+func NewSettingsPlugin() *SettingsPlugin {
+	return &SettingsPlugin{}
+}
+
+// GetName mengembalikan nama plugin
+func (p *SettingsPlugin) GetName() string {
+	return "settings"
+}
+
+// GetCommands mengembalikan daftar command yang didukung
+func (p *SettingsPlugin) GetCommands() []string {
+	return []string{"settings"}
+}
+
+// GetCommandSpecs mengembalikan metadata command settings beserta pohon subcommand-nya
+func (p *SettingsPlugin) GetCommandSpecs() []lib.CommandSpec {
+	// Role diatur per subcommand agar feedback bisa dipakai semua user di chat pribadi
+	return []lib.CommandSpec{
+		{
+			Name:        "settings",
+			Aliases:     []string{"setting", "set"},
+			Category:    "Admin",
+			Description: "Atur perilaku bot di chat ini",
+			Examples:    []string{"settings show", "settings suggest off", "settings feedback off"},
+			Subcommands: []lib.Subcommand{
+				{
+					Name:        "show",
+					Description: "Lihat pengaturan chat ini",
+					Role:        lib.RoleGroupAdmin,
+					Handler:     p.show,
+				},
+				{
+					Name:        "suggest",
+					Description: "Nyalakan atau matikan saran untuk command yang salah ketik",
+					Role:        lib.RoleGroupAdmin,
+					Args:        []lib.ArgSpec{{Name: "on|off", Description: "on untuk menyalakan, off untuk mematikan"}},
+					Handler:     p.suggest,
+				},
+				{
+					Name:        "feedback",
+					Description: "Nyalakan atau matikan reaksi status (⏳/✅/❌) dan status mengetik saat command berjalan (grup: khusus admin)",
+					Args:        []lib.ArgSpec{{Name: "on|off", Description: "on untuk menyalakan, off untuk mematikan"}},
+					Handler:     p.feedback,
+				},
+				{
+					Name:        "unknown",
+					Description: "Lihat command tidak dikenal yang paling sering diketik",
+					Role:        lib.RoleOwner,
+					Handler:     p.unknown,
+					Subcommands: []lib.Subcommand{
+						{
+							Name:        "reset",
+							Description: "Hapus statistik command tidak dikenal",
+							Handler:     p.resetUnknown,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// GetDescription mengembalikan deskripsi plugin
+func (p *SettingsPlugin) GetDescription() string {
+	return "Plugin untuk mengatur perilaku bot per chat"
+}
+
+// IsEssential menandai plugin ini tidak boleh dinonaktifkan
+func (p *SettingsPlugin) IsEssential() bool {
+	return true
+}
+
+// Handle tidak dipakai karena semua command settings diteruskan ke subcommand
+func (p *SettingsPlugin) Handle(ctx *lib.Context) error {
+	return nil
+}
+
+// show menampilkan pengaturan chat ini
+func (p *SettingsPlugin) show(ctx *lib.Context) error {
+	settings := ctx.Manager.ChatSettings()
+	if settings == nil {
+		return ctx.Reply("❌ Pengaturan chat tidak aktif di bot ini.")
+	}
+
+	return ctx.Replyf("⚙️ *Pengaturan Chat:*\n\n• Saran command: %s\n• Reaksi status command: %s",
+		toggleText(settings.Bool(ctx.Chat, lib.SettingSuggestions, true)),
+		toggleText(settings.Bool(ctx.Chat, lib.SettingFeedback, true)))
+}
+
+// suggest menyalakan atau mematikan saran command di chat ini
+func (p *SettingsPlugin) suggest(ctx *lib.Context) error {
+	settings := ctx.Manager.ChatSettings()
+	if settings == nil {
+		return ctx.Reply("❌ Pengaturan chat tidak aktif di bot ini.")
+	}
+
+	enabled, ok := parseToggle(ctx.Params.String("on|off"))
+	if !ok {
+		return ctx.ReplyUsage(fmt.Sprintf("Status *%s* tidak dikenal, gunakan *on* atau *off*.", ctx.Params.String("on|off")))
+	}
+
+	if err := settings.SetBool(ctx.Chat, lib.SettingSuggestions, enabled); err != nil {
+		return err
+	}
+	return ctx.Replyf("✅ Saran command %s di chat ini.", toggleText(enabled))
+}
+
+// feedback menyalakan atau mematikan reaksi status dan status mengetik di chat ini.
+// Di chat pribadi semua user boleh mengaturnya, di grup hanya admin.
+func (p *SettingsPlugin) feedback(ctx *lib.Context) error {
+	if ctx.IsGroup && ctx.Role() < lib.RoleGroupAdmin {
+		return ctx.Reply(lib.DeniedMessage(lib.RoleGroupAdmin))
+	}
+
+	settings := ctx.Manager.ChatSettings()
+	if settings == nil {
+		return ctx.Reply("❌ Pengaturan chat tidak aktif di bot ini.")
+	}
+
+	enabled, ok := parseToggle(ctx.Params.String("on|off"))
+	if !ok {
+		return ctx.ReplyUsage(fmt.Sprintf("Status *%s* tidak dikenal, gunakan *on* atau *off*.", ctx.Params.String("on|off")))
+	}
+
+	if err := settings.SetBool(ctx.Chat, lib.SettingFeedback, enabled); err != nil {
+		return err
+	}
+	return ctx.Replyf("✅ Reaksi status command %s di chat ini.", toggleText(enabled))
+}
+
+// unknown menampilkan command tidak dikenal yang paling sering diketik
+func (p *SettingsPlugin) unknown(ctx *lib.Context) error {
+	stats := ctx.Manager.UnknownCommandStats()
+	if stats == nil {
+		return ctx.Reply("❌ Statistik command tidak dikenal tidak aktif di bot ini.")
+	}
+
+	commands, err := stats.Top(10)
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
+		return ctx.Reply("📊 Belum ada command tidak dikenal yang tercatat.")
+	}
+
+	var text strings.Builder
+	text.WriteString("📊 *Command Tidak Dikenal Terbanyak:*\n\n")
+	for i, command := range commands {
+		text.WriteString(fmt.Sprintf("%d. `%s%s` - %d kali (terakhir %s)\n", i+1, ctx.Prefix, command.Command, command.Count, command.LastSeen.Format("02/01/2006 15:04")))
+	}
+	return ctx.Reply(text.String())
+}
+
+// resetUnknown menghapus statistik command tidak dikenal
+func (p *SettingsPlugin) resetUnknown(ctx *lib.Context) error {
+	stats := ctx.Manager.UnknownCommandStats()
+	if stats == nil {
+		return ctx.Reply("❌ Statistik command tidak dikenal tidak aktif di bot ini.")
+	}
+
+	if err := stats.Reset(); err != nil {
+		return err
+	}
+	return ctx.Reply("🗑️ Statistik command tidak dikenal dihapus.")
+}
+
+// parseToggle mengubah teks on/off menjadi boolean
+func parseToggle(value string) (enabled bool, ok bool) {
+	switch strings.ToLower(value) {
+	case "on", "ya", "aktif", "true":
+		return true, true
+	case "off", "tidak", "nonaktif", "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// toggleText mengubah boolean menjadi teks status
+func toggleText(enabled bool) string {
+	if enabled {
+		return "aktif ✅"
+	}
+	return "nonaktif ⛔"
+}
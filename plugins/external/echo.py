@@ -0,0 +1,82 @@
+#!/usr/bin/env python3
+"""Contoh plugin eksternal Furina-Go.
+
+Bot menjalankan script ini dan berkomunikasi lewat JSON-RPC 2.0 di
+stdin/stdout, satu pesan JSON per baris. Jangan print apa pun selain
+JSON ke stdout; gunakan stderr untuk log.
+"""
+import json
+import sys
+import threading
+
+_lock = threading.Lock()
+_next_id = 0
+
+
+def write(message):
+    with _lock:
+        sys.stdout.write(json.dumps(message) + "\n")
+        sys.stdout.flush()
+
+
+def call(method, params):
+    """Kirim request ke bot (reply, react, send, log) tanpa menunggu hasil."""
+    global _next_id
+    _next_id += 1
+    write({"jsonrpc": "2.0", "id": f"plugin-{_next_id}", "method": method, "params": params})
+
+
+def handle(params):
+    if params["command"] == "echo":
+        text = " ".join(params["args"]) or "Tidak ada teks untuk diulang."
+        return {"reply": f"🔁 {text}"}
+    if params["command"] == "shout":
+        call("react", {"token": params["token"], "emoji": "📢"})
+        return {"reply": " ".join(params["args"]).upper()}
+    return None
+
+
+def main():
+    for line in sys.stdin:
+        message = json.loads(line)
+        method = message.get("method")
+        if method is None:
+            continue  # response dari bot untuk call()
+
+        if method == "initialize":
+            result = {
+                "description": "Contoh plugin eksternal berbasis Python",
+                "commands": [
+                    {
+                        "name": "echo",
+                        "aliases": ["say"],
+                        "category": "Contoh",
+                        "description": "Ulangi teks yang dikirim",
+                        "usage": "<teks>",
+                        "examples": ["echo halo"],
+                    },
+                    {
+                        "name": "shout",
+                        "category": "Contoh",
+                        "description": "Ulangi teks dengan huruf besar",
+                        "usage": "<teks>",
+                    },
+                ],
+            }
+        elif method == "handle":
+            result = handle(message["params"])
+        elif method == "ping":
+            result = "pong"
+        elif method == "shutdown":
+            return
+        else:
+            write({"jsonrpc": "2.0", "id": message.get("id"),
+                   "error": {"code": -32601, "message": "method not found"}})
+            continue
+
+        if "id" in message:
+            write({"jsonrpc": "2.0", "id": message["id"], "result": result})
+
+
+if __name__ == "__main__":
+    main()
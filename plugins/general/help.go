@@ -2,13 +2,15 @@ package general
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
-	"go.mau.fi/whatsmeow"
-	"go.mau.fi/whatsmeow/types/events"
 	"furina-bot/lib"
 )
 
+// helpPageSize adalah jumlah command per halaman menu
+const helpPageSize = 10
+
 // HelpPlugin adalah plugin untuk command help
 type HelpPlugin struct{}
 
@@ -30,63 +32,224 @@ func (h *HelpPlugin) GetCommands() []string {
 	return []string{"menu"}
 }
 
+// GetCommandSpecs mengembalikan metadata command help
+func (h *HelpPlugin) GetCommandSpecs() []lib.CommandSpec {
+	return []lib.CommandSpec{
+		{
+			Name:        "menu",
+			Aliases:     []string{"help"},
+			Category:    "Umum",
+			Description: "Tampilkan daftar command atau detail satu command",
+			Usage:       "[halaman | command [subcommand]]",
+			Examples:    []string{"menu", "menu 2", "menu ping", "menu plugin enable"},
+		},
+	}
+}
+
 // GetDescription mengembalikan deskripsi plugin
 func (h *HelpPlugin) GetDescription() string {
 	return "Plugin untuk menampilkan bantuan dan daftar command yang tersedia"
 }
 
-// HandleMessage menangani pesan help
-func (h *HelpPlugin) HandleMessage(client *whatsmeow.Client, message *events.Message) error {
-	messageText := message.Message.GetConversation()
-	senderJID := message.Info.Sender
-
-	// Parse command menggunakan command parser
-	commandParser := lib.NewCommandParser(lib.DefaultCommandConfig())
-	command, _, isCommand := commandParser.ParseCommand(messageText)
-	
-	if !isCommand {
-		return nil
-	}
-
+// Handle menangani command help
+func (h *HelpPlugin) Handle(ctx *lib.Context) error {
 	var responseText string
-	
-	switch command {
-	case "menu":
-		responseText = h.generateHelpText()
+
+	switch {
+	case len(ctx.Args) == 0:
+		responseText = h.generateHelpText(ctx, 1)
 	default:
-		return nil
+		if page, err := strconv.Atoi(ctx.Args[0]); err == nil {
+			responseText = h.generateHelpText(ctx, page)
+		} else {
+			responseText = h.generateCommandHelp(ctx, ctx.Args[0], ctx.Args[1:])
+		}
 	}
 
-	// Kirim balasan dengan reply menggunakan helper function
-	err := lib.SendReplyMessage(client, message, responseText)
+	// Kirim balasan dengan reply
+	err := ctx.Reply(responseText)
 
 	if err != nil {
-		fmt.Printf("❌ Gagal mengirim pesan help ke %s: %v\n", senderJID, err)
+		fmt.Printf("❌ Gagal mengirim pesan help ke %s: %v\n", ctx.Sender, err)
 		return err
 	}
 
 	return nil
 }
 
-// generateHelpText menghasilkan teks bantuan
-func (h *HelpPlugin) generateHelpText() string {
+// generateHelpText menghasilkan satu halaman daftar command yang dikelompokkan per kategori.
+// Hanya command yang boleh dijalankan pengirim yang ditampilkan.
+func (h *HelpPlugin) generateHelpText(ctx *lib.Context, page int) string {
+	commands := ctx.Manager.AvailableCommands(ctx)
+
+	pages := (len(commands) + helpPageSize - 1) / helpPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+	if page > pages {
+		page = pages
+	}
+
+	start := (page - 1) * helpPageSize
+	end := start + helpPageSize
+	if end > len(commands) {
+		end = len(commands)
+	}
+
+	// Kelompokkan semua command dulu agar urutan kategori sama di setiap halaman
+	var ordered []*lib.Command
+	for _, category := range lib.GroupByCategory(commands) {
+		ordered = append(ordered, category.Commands...)
+	}
+
 	var help strings.Builder
-	
+
 	help.WriteString("🤖 *Furina-Go Bot - Bantuan*\n\n")
-	help.WriteString("📋 *Daftar Command yang Tersedia:*\n\n")
-	
-	// General Commands
-	help.WriteString("🔧 *General Commands:*\n")
-	help.WriteString("• `!ping` - Cek status bot dan info sistem\n")
-	help.WriteString("• `!menu` - Tampilkan bantuan ini\n\n")
-	
+	help.WriteString("📋 *Daftar Command yang Tersedia:*\n")
+
+	for _, category := range lib.GroupByCategory(ordered[start:end]) {
+		help.WriteString(fmt.Sprintf("\n📂 *%s:*\n", category.Name))
+		for _, cmd := range category.Commands {
+			description := cmd.Description
+			if description == "" {
+				description = cmd.Plugin.GetDescription()
+			}
+			help.WriteString(fmt.Sprintf("• `%s%s` - %s\n", ctx.Prefix, cmd.Name, description))
+		}
+	}
+
 	// Bot Info
-	help.WriteString("ℹ️ *Informasi Bot:*\n")
-	help.WriteString("• Prefix: `!` (tanda seru)\n\n")
-	
+	help.WriteString("\nℹ️ *Informasi Bot:*\n")
+	help.WriteString(fmt.Sprintf("• Prefix: `%s`\n", ctx.Prefix))
+	help.WriteString(fmt.Sprintf("• Halaman %d/%d", page, pages))
+	if page < pages {
+		help.WriteString(fmt.Sprintf(" - ketik `%smenu %d` untuk halaman berikutnya", ctx.Prefix, page+1))
+	}
+	help.WriteString("\n")
+	help.WriteString(fmt.Sprintf("• Ketik `%smenu <command>` untuk detail pemakaian\n\n", ctx.Prefix))
+
 	// Footer
 	help.WriteString("✨ *Furina-Go Bot v1.0*\n")
 	help.WriteString("🔗 Powered by Papah-Chan\n")
-	
+
 	return help.String()
-}
\ No newline at end of file
+}
+
+// generateCommandHelp menghasilkan detail pemakaian satu command atau subcommand-nya
+func (h *HelpPlugin) generateCommandHelp(ctx *lib.Context, name string, path []string) string {
+	name = strings.TrimPrefix(name, ctx.Prefix)
+
+	cmd, ok := ctx.Manager.LookupCommand(name)
+	if !ok || !ctx.Manager.CanRun(ctx, cmd) {
+		return fmt.Sprintf("❌ Command *%s* tidak ditemukan. Ketik `%smenu` untuk melihat daftar command.", name, ctx.Prefix)
+	}
+
+	// Detail subcommand jika path cocok dengan pohon subcommand
+	if sub, used := lib.FindSubcommand(cmd.Subcommands, path); sub != nil && ctx.CanUse(sub.Role) {
+		return h.generateSubcommandHelp(ctx, cmd.Name+" "+strings.Join(path[:used], " "), sub)
+	}
+
+	var help strings.Builder
+
+	help.WriteString(fmt.Sprintf("📖 *Bantuan Command %s%s*\n\n", ctx.Prefix, cmd.Name))
+
+	description := cmd.Description
+	if description == "" {
+		description = cmd.Plugin.GetDescription()
+	}
+	help.WriteString(fmt.Sprintf("%s\n\n", description))
+
+	usage := ctx.Prefix + cmd.Name
+	if text := cmd.UsageText(); text != "" {
+		usage += " " + text
+	}
+	help.WriteString(fmt.Sprintf("• Pemakaian: `%s`\n", usage))
+	help.WriteString(fmt.Sprintf("• Kategori: %s\n", cmd.CategoryName()))
+
+	if len(cmd.Aliases) > 0 {
+		aliases := make([]string, len(cmd.Aliases))
+		for i, alias := range cmd.Aliases {
+			aliases[i] = fmt.Sprintf("`%s%s`", ctx.Prefix, alias)
+		}
+		help.WriteString(fmt.Sprintf("• Alias: %s\n", strings.Join(aliases, ", ")))
+	}
+	if cmd.Role > lib.RoleMember {
+		help.WriteString(fmt.Sprintf("• Khusus: %s\n", cmd.Role))
+	}
+	if cmd.Cooldown != nil {
+		help.WriteString(fmt.Sprintf("• Cooldown: %s\n", lib.FormatDuration(cmd.Cooldown.Duration)))
+	}
+
+	if len(cmd.Subcommands) > 0 {
+		help.WriteString("\n🌳 *Subcommand:*\n")
+		help.WriteString(lib.RenderSubcommands(ctx, cmd.Name, cmd.Subcommands))
+	}
+
+	h.writeArgs(&help, cmd.Args, cmd.Flags)
+	h.writeExamples(&help, ctx.Prefix, cmd.Examples)
+
+	return help.String()
+}
+
+// generateSubcommandHelp menghasilkan detail pemakaian satu subcommand
+func (h *HelpPlugin) generateSubcommandHelp(ctx *lib.Context, path string, sub *lib.Subcommand) string {
+	var help strings.Builder
+
+	help.WriteString(fmt.Sprintf("📖 *Bantuan Command %s%s*\n\n", ctx.Prefix, path))
+	if sub.Description != "" {
+		help.WriteString(fmt.Sprintf("%s\n\n", sub.Description))
+	}
+
+	usage := ctx.Prefix + path
+	if text := sub.UsageText(); text != "" {
+		usage += " " + text
+	}
+	help.WriteString(fmt.Sprintf("• Pemakaian: `%s`\n", usage))
+
+	if len(sub.Aliases) > 0 {
+		help.WriteString(fmt.Sprintf("• Alias: %s\n", strings.Join(sub.Aliases, ", ")))
+	}
+	if sub.Role > lib.RoleMember {
+		help.WriteString(fmt.Sprintf("• Khusus: %s\n", sub.Role))
+	}
+
+	if len(sub.Subcommands) > 0 {
+		help.WriteString("\n🌳 *Subcommand:*\n")
+		help.WriteString(lib.RenderSubcommands(ctx, path, sub.Subcommands))
+	}
+
+	h.writeArgs(&help, sub.Args, sub.Flags)
+	h.writeExamples(&help, ctx.Prefix, sub.Examples)
+
+	return help.String()
+}
+
+// writeArgs menulis penjelasan argumen dan flag
+func (h *HelpPlugin) writeArgs(help *strings.Builder, args []lib.ArgSpec, flags []lib.FlagSpec) {
+	if len(args) == 0 && len(flags) == 0 {
+		return
+	}
+
+	help.WriteString("\n🧾 *Argumen:*\n")
+	for _, arg := range args {
+		help.WriteString(fmt.Sprintf("• `%s` - %s\n", arg.Name, arg.Description))
+	}
+	for _, flag := range flags {
+		help.WriteString(fmt.Sprintf("• `--%s` - %s\n", flag.Name, flag.Description))
+	}
+}
+
+// writeExamples menulis contoh pemakaian
+func (h *HelpPlugin) writeExamples(help *strings.Builder, prefix string, examples []string) {
+	if len(examples) == 0 {
+		return
+	}
+
+	help.WriteString("\n💡 *Contoh:*\n")
+	for _, example := range examples {
+		help.WriteString(fmt.Sprintf("• `%s%s`\n", prefix, example))
+	}
+}
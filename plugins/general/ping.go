@@ -5,8 +5,6 @@ import (
 	"runtime"
 	"time"
 
-	"go.mau.fi/whatsmeow"
-	"go.mau.fi/whatsmeow/types/events"
 	"furina-bot/lib"
 )
 
@@ -34,28 +32,29 @@ func (p *PingPlugin) GetCommands() []string {
 	return []string{"ping"}
 }
 
+// GetCommandSpecs mengembalikan metadata command ping
+func (p *PingPlugin) GetCommandSpecs() []lib.CommandSpec {
+	return []lib.CommandSpec{
+		{
+			Name:        "ping",
+			Category:    "Umum",
+			Description: "Cek status bot dan info sistem",
+			Examples:    []string{"ping"},
+		},
+	}
+}
+
 // GetDescription mengembalikan deskripsi plugin
 func (p *PingPlugin) GetDescription() string {
 	return "Plugin sederhana untuk test koneksi bot"
 }
 
-// HandleMessage menangani pesan ping
-func (p *PingPlugin) HandleMessage(client *whatsmeow.Client, message *events.Message) error {
-	messageText := message.Message.GetConversation()
-	senderJID := message.Info.Sender
-
-	// Parse command menggunakan command parser
-	commandParser := lib.NewCommandParser(lib.DefaultCommandConfig())
-	command, _, isCommand := commandParser.ParseCommand(messageText)
-	
-	if !isCommand {
-		return nil
-	}
-
+// Handle menangani command ping
+func (p *PingPlugin) Handle(ctx *lib.Context) error {
 	var responseText string
 	startTime := time.Now()
 	
-	switch command {
+	switch ctx.Command.Name {
 	case "ping":
 		// Hitung runtime info
 		var m runtime.MemStats
@@ -72,25 +71,51 @@ func (p *PingPlugin) HandleMessage(client *whatsmeow.Client, message *events.Mes
 • 🔧 Go Version: %s
 • ⚡ Goroutines: %d
 • ⏰ Response Time: %v
-
+%s
 ✨ Bot berjalan dengan lancar!`,
 			uptime.Round(time.Second),
 			float64(m.Alloc)/1024/1024,
 			runtime.Version(),
 			runtime.NumGoroutine(),
 			time.Since(startTime),
+			queueInfo(ctx),
 		)
 	default:
 		return nil
 	}
 
-	// Kirim balasan dengan reply menggunakan helper function
-	err := lib.SendReplyMessage(client, message, responseText)
+	// Kirim balasan dengan reply
+	err := ctx.Reply(responseText)
 
 	if err != nil {
-		fmt.Printf("❌ Gagal mengirim pesan ke %s: %v\n", senderJID, err)
+		fmt.Printf("❌ Gagal mengirim pesan ke %s: %v\n", ctx.Sender, err)
 		return err
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// queueInfo menampilkan metrik antrean dispatcher jika tersedia
+func queueInfo(ctx *lib.Context) string {
+	dispatcher := ctx.Manager.Dispatcher()
+	if dispatcher == nil {
+		return ""
+	}
+
+	stats := dispatcher.Stats()
+	return fmt.Sprintf(`
+📬 *Message Queue:*
+• 📥 Queued: %d (%d chat)
+• ⚙️ Processing: %d
+• ✅ Processed: %d
+• ⛔ Dropped: %d
+• ⏱️ Avg Latency: %v
+`,
+		stats.Queued,
+		stats.Chats,
+		stats.Busy,
+		stats.Processed,
+		stats.Dropped,
+		stats.AverageLatency.Round(time.Millisecond),
+	)
+}
@@ -0,0 +1,436 @@
+package general
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"furina-bot/lib"
+)
+
+const (
+	// pollMaxOptions adalah jumlah pilihan maksimal polling WhatsApp
+	pollMaxOptions = 12
+
+	// pollSweepInterval adalah jarak pengecekan polling yang harus ditutup otomatis
+	pollSweepInterval = 30 * time.Second
+)
+
+// PollPlugin adalah plugin polling native WhatsApp dengan rekap suara di database
+type PollPlugin struct {
+	manager      *lib.PluginManager
+	db           *sql.DB
+	errorHandler *lib.ErrorHandler
+	store        *pollStore
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Pastikan PollPlugin mengimplementasikan interface yang dibutuhkan
+var (
+	_ lib.Plugin           = (*PollPlugin)(nil)
+	_ lib.ListenerProvider = (*PollPlugin)(nil)
+	_ lib.Initializer      = (*PollPlugin)(nil)
+	_ lib.Starter          = (*PollPlugin)(nil)
+	_ lib.Stopper          = (*PollPlugin)(nil)
+)
+
+// NewPollPlugin membuat instance baru PollPlugin. Polling dan suara disimpan di db,
+// dan hasil polling yang ditutup otomatis dikirim lewat client milik manager.
+func NewPollPlugin(manager *lib.PluginManager, db *sql.DB, errorHandler *lib.ErrorHandler) *PollPlugin {
+	return &PollPlugin{
+		manager:      manager,
+		db:           db,
+		errorHandler: errorHandler,
+	}
+}
+
+// GetName mengembalikan nama plugin
+func (p *PollPlugin) GetName() string {
+	return "poll"
+}
+
+// GetCommands mengembalikan daftar command yang didukung
+func (p *PollPlugin) GetCommands() []string {
+	return []string{"poll", "pollresult", "pollclose"}
+}
+
+// GetCommandSpecs mengembalikan metadata command polling
+func (p *PollPlugin) GetCommandSpecs() []lib.CommandSpec {
+	return []lib.CommandSpec{
+		{
+			Name:        "poll",
+			Aliases:     []string{"polling"},
+			Category:    "Umum",
+			Description: "Buat polling WhatsApp, pilihan dipisah tanda |",
+			Usage:       `[--multi] [--close durasi] "pertanyaan" pilihan 1 | pilihan 2 | ...`,
+			Args: []lib.ArgSpec{
+				{Name: "pertanyaan", Description: "Pertanyaan polling, pakai tanda kutip jika lebih dari satu kata"},
+				{Name: "pilihan", Type: lib.ArgRest, Description: "Pilihan jawaban dipisah tanda |"},
+			},
+			Flags: []lib.FlagSpec{
+				{Name: "multi", Type: lib.ArgBool, Description: "Izinkan memilih lebih dari satu pilihan"},
+				{Name: "close", Type: lib.ArgDuration, Description: "Tutup otomatis setelah durasi ini, misalnya 30m atau 1d"},
+			},
+			Cooldown: &lib.Cooldown{Duration: 10 * time.Second, Scope: lib.CooldownUser},
+			Examples: []string{
+				`poll "Makan siang?" pizza | sushi | padang`,
+				`poll --multi --close 1d "Bisa main kapan?" sabtu | minggu`,
+			},
+		},
+		{
+			Name:        "pollresult",
+			Aliases:     []string{"hasilpoll"},
+			Category:    "Umum",
+			Description: "Lihat hasil polling yang dibalas, atau polling terakhir di chat ini",
+			Examples:    []string{"pollresult"},
+		},
+		{
+			Name:        "pollclose",
+			Aliases:     []string{"closepoll", "tutuppoll"},
+			Category:    "Umum",
+			Description: "Tutup polling dan kirim hasil akhirnya (pembuat polling atau admin grup)",
+			Examples:    []string{"pollclose"},
+		},
+	}
+}
+
+// GetDescription mengembalikan deskripsi plugin
+func (p *PollPlugin) GetDescription() string {
+	return "Plugin untuk membuat polling dan merekap suaranya"
+}
+
+// GetListeners mendaftarkan listener yang mencatat suara polling
+func (p *PollPlugin) GetListeners() []lib.Listener {
+	return []lib.Listener{
+		{
+			Name:    "poll-vote",
+			Trigger: lib.TriggerAll,
+			Handler: p.handleVote,
+		},
+	}
+}
+
+// Init menyiapkan tabel polling di database
+func (p *PollPlugin) Init(ctx context.Context) error {
+	store, err := newPollStore(p.db)
+	if err != nil {
+		return err
+	}
+	p.store = store
+	return nil
+}
+
+// Start menjalankan pengecekan berkala untuk menutup polling otomatis
+func (p *PollPlugin) Start(ctx context.Context) error {
+	loopCtx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(pollSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-loopCtx.Done():
+				return
+			case <-ticker.C:
+				if err := p.closeDue(loopCtx); err != nil && p.errorHandler != nil {
+					p.errorHandler.LogError(err, "poll.closeDue")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop menghentikan pengecekan polling otomatis
+func (p *PollPlugin) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Handle menangani command polling
+func (p *PollPlugin) Handle(ctx *lib.Context) error {
+	if p.store == nil {
+		return ctx.Reply("❌ Polling belum siap, coba lagi nanti.")
+	}
+
+	switch ctx.Command.Name {
+	case "poll":
+		return p.create(ctx)
+	case "pollresult":
+		return p.result(ctx)
+	case "pollclose":
+		return p.close(ctx)
+	default:
+		return nil
+	}
+}
+
+// create mengirim polling baru lalu menyimpannya
+func (p *PollPlugin) create(ctx *lib.Context) error {
+	question := strings.TrimSpace(ctx.Params.String("pertanyaan"))
+	options := splitPollOptions(ctx.Params.String("pilihan"))
+	switch {
+	case question == "":
+		return ctx.ReplyUsage("Pertanyaan polling tidak boleh kosong.")
+	case len(options) < 2:
+		return ctx.ReplyUsage("Polling butuh minimal 2 pilihan berbeda, pisahkan dengan tanda |.")
+	case len(options) > pollMaxOptions:
+		return ctx.ReplyUsage(fmt.Sprintf("Polling maksimal %d pilihan.", pollMaxOptions))
+	}
+
+	// 0 berarti user boleh memilih berapa pun pilihan
+	selectable := 1
+	if ctx.Params.Bool("multi") {
+		selectable = 0
+	}
+
+	var closesAt time.Time
+	if ctx.Params.Has("close") {
+		closesAt = time.Now().Add(ctx.Params.Duration("close"))
+	}
+
+	resp, err := ctx.NewMessage().Poll(question, options, selectable).Send(ctx.Ctx)
+	if err != nil {
+		return fmt.Errorf("failed to send poll: %v", err)
+	}
+
+	err = p.store.create(&pollRecord{
+		ID:         resp.ID,
+		Chat:       ctx.Chat,
+		Creator:    ctx.Sender.ToNonAD(),
+		Question:   question,
+		Options:    options,
+		Selectable: selectable,
+		CreatedAt:  resp.Timestamp,
+		ClosesAt:   closesAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !closesAt.IsZero() {
+		return ctx.Replyf("⏰ Polling akan ditutup otomatis pada %s. Ketik *%spollresult* untuk melihat hasil sementara.", closesAt.Format("02/01/2006 15:04"), ctx.Prefix)
+	}
+	return nil
+}
+
+// result menampilkan hasil polling
+func (p *PollPlugin) result(ctx *lib.Context) error {
+	poll, err := p.findPoll(ctx)
+	if err != nil || poll == nil {
+		return err
+	}
+
+	summary, err := p.summary(poll)
+	if err != nil {
+		return err
+	}
+	return ctx.Reply(summary)
+}
+
+// close menutup polling lalu mengirim hasil akhirnya
+func (p *PollPlugin) close(ctx *lib.Context) error {
+	poll, err := p.findPoll(ctx)
+	if err != nil || poll == nil {
+		return err
+	}
+
+	if poll.Creator != ctx.Sender.ToNonAD() && !ctx.CanUse(lib.RoleGroupAdmin) {
+		return ctx.Reply("❌ Hanya pembuat polling atau admin grup yang bisa menutup polling ini.")
+	}
+
+	closed, err := p.store.close(poll.ID)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return ctx.Replyf("ℹ️ Polling *%s* sudah ditutup sebelumnya. Ketik *%spollresult* untuk melihat hasilnya.", poll.Question, ctx.Prefix)
+	}
+	poll.Closed = true
+
+	summary, err := p.summary(poll)
+	if err != nil {
+		return err
+	}
+	return ctx.Reply(summary)
+}
+
+// findPoll mencari polling dari pesan yang dibalas, atau polling terakhir di chat.
+// Membalas user dan mengembalikan nil jika tidak ada.
+func (p *PollPlugin) findPoll(ctx *lib.Context) (*pollRecord, error) {
+	if ctx.Quoted != nil {
+		poll, err := p.store.get(ctx.Quoted.ID)
+		if err != nil {
+			return nil, err
+		}
+		if poll != nil && poll.Chat == ctx.Chat {
+			return poll, nil
+		}
+	}
+
+	poll, err := p.store.latest(ctx.Chat)
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil {
+		return nil, ctx.Replyf("📊 Belum ada polling di chat ini. Buat dengan *%spoll*.", ctx.Prefix)
+	}
+	return poll, nil
+}
+
+// handleVote mendekripsi vote polling dan menyimpan pilihan terbaru user
+func (p *PollPlugin) handleVote(ctx *lib.Context) error {
+	update := ctx.Event.Message.GetPollUpdateMessage()
+	if update == nil || p.store == nil {
+		return nil
+	}
+
+	poll, err := p.store.get(update.GetPollCreationMessageKey().GetID())
+	if err != nil {
+		return err
+	}
+	// Polling yang tidak dibuat lewat bot atau sudah ditutup diabaikan
+	if poll == nil || poll.isClosed(time.Now()) {
+		return nil
+	}
+
+	vote, err := ctx.Client.DecryptPollVote(ctx.Ctx, ctx.Event)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt vote for poll %s: %v", poll.ID, err)
+	}
+	return p.store.setVote(poll.ID, ctx.Sender.ToNonAD(), poll.optionNames(vote.GetSelectedOptions()))
+}
+
+// closeDue menutup polling yang waktunya habis dan mengirim hasil akhirnya ke chat
+func (p *PollPlugin) closeDue(ctx context.Context) error {
+	polls, err := p.store.due(time.Now())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, poll := range polls {
+		closed, err := p.store.close(poll.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !closed {
+			continue
+		}
+		poll.Closed = true
+
+		summary, err := p.summary(poll)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := lib.NewMessage(p.manager.Client(), poll.Chat).Text(summary).Send(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send result of poll %s to %s: %v", poll.ID, poll.Chat, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// summary menyusun teks hasil polling
+func (p *PollPlugin) summary(poll *pollRecord) (string, error) {
+	result, err := p.store.result(poll)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	var text strings.Builder
+	if poll.isClosed(now) {
+		text.WriteString("📊 *Hasil Akhir Polling*\n")
+	} else {
+		text.WriteString("📊 *Hasil Sementara Polling*\n")
+	}
+	text.WriteString(fmt.Sprintf("❓ %s\n\n", poll.Question))
+
+	total := 0
+	for _, votes := range result.Votes {
+		total += votes
+	}
+
+	for i, option := range poll.Options {
+		percent := 0
+		if total > 0 {
+			percent = result.Votes[i] * 100 / total
+		}
+		text.WriteString(fmt.Sprintf("%d. %s — %d suara (%d%%)\n   %s\n", i+1, option, result.Votes[i], percent, pollBar(percent)))
+	}
+
+	text.WriteString(fmt.Sprintf("\n👥 Total pemilih: %d", result.Voters))
+	switch {
+	case poll.isClosed(now):
+		if winners := pollWinners(poll.Options, result.Votes); len(winners) > 0 {
+			text.WriteString(fmt.Sprintf("\n🏆 Pemenang: %s", strings.Join(winners, ", ")))
+		}
+		text.WriteString("\n🔒 Polling sudah ditutup")
+	case !poll.ClosesAt.IsZero():
+		text.WriteString(fmt.Sprintf("\n⏰ Ditutup otomatis pada %s", poll.ClosesAt.Format("02/01/2006 15:04")))
+	}
+	return text.String(), nil
+}
+
+// splitPollOptions memecah teks pilihan berdasarkan tanda | dan membuang
+// pilihan kosong atau kembar (WhatsApp menolak pilihan yang sama)
+func splitPollOptions(text string) []string {
+	var options []string
+	seen := make(map[string]bool)
+	for _, option := range strings.Split(text, "|") {
+		option = strings.TrimSpace(option)
+		key := strings.ToLower(option)
+		if option == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, option)
+	}
+	return options
+}
+
+// pollWinners mengembalikan pilihan dengan suara terbanyak, kosong jika belum ada suara
+func pollWinners(options []string, votes []int) []string {
+	best := 0
+	for _, count := range votes {
+		best = max(best, count)
+	}
+	if best == 0 {
+		return nil
+	}
+
+	var winners []string
+	for i, count := range votes {
+		if count == best {
+			winners = append(winners, options[i])
+		}
+	}
+	return winners
+}
+
+// pollBar membuat bar persentase sepanjang 10 blok
+func pollBar(percent int) string {
+	filled := (percent + 5) / 10
+	return strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
+}
@@ -0,0 +1,243 @@
+package general
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// pollRecord adalah polling native WhatsApp yang dibuat lewat bot
+type pollRecord struct {
+	ID         types.MessageID
+	Chat       types.JID
+	Creator    types.JID
+	Question   string
+	Options    []string
+	Selectable int
+	CreatedAt  time.Time
+
+	// ClosesAt adalah waktu tutup otomatis (zero jika tidak ada)
+	ClosesAt time.Time
+	Closed   bool
+}
+
+// isClosed mengecek apakah polling sudah ditutup atau sudah lewat waktu tutupnya
+func (p *pollRecord) isClosed(now time.Time) bool {
+	return p.Closed || (!p.ClosesAt.IsZero() && !now.Before(p.ClosesAt))
+}
+
+// optionNames mengubah hash SHA-256 pilihan dari vote menjadi nama pilihan
+func (p *pollRecord) optionNames(hashes [][]byte) []string {
+	known := make(map[string]string, len(p.Options))
+	for i, hash := range whatsmeow.HashPollOptions(p.Options) {
+		known[string(hash)] = p.Options[i]
+	}
+
+	var names []string
+	for _, hash := range hashes {
+		if name, ok := known[string(hash)]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// pollResult adalah rekap suara satu polling
+type pollResult struct {
+	// Votes adalah jumlah suara per pilihan, urut sesuai Options
+	Votes []int
+
+	// Voters adalah jumlah user yang memilih setidaknya satu pilihan
+	Voters int
+}
+
+// pollStore menyimpan polling dan suara terakhir setiap user di SQLite
+type pollStore struct {
+	db *sql.DB
+}
+
+// newPollStore membuat instance baru pollStore dan tabelnya
+func newPollStore(db *sql.DB) (*pollStore, error) {
+	ps := &pollStore{db: db}
+	if err := ps.initializeTable(); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// initializeTable membuat tabel polling dan suara jika belum ada
+func (ps *pollStore) initializeTable() error {
+	_, err := ps.db.Exec(`CREATE TABLE IF NOT EXISTS furina_polls (
+		id         TEXT PRIMARY KEY,
+		chat       TEXT NOT NULL,
+		creator    TEXT NOT NULL,
+		question   TEXT NOT NULL,
+		options    TEXT NOT NULL,
+		selectable INTEGER NOT NULL,
+		created_at INTEGER NOT NULL,
+		closes_at  INTEGER NOT NULL DEFAULT 0,
+		closed     INTEGER NOT NULL DEFAULT 0
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create poll table: %v", err)
+	}
+
+	_, err = ps.db.Exec(`CREATE TABLE IF NOT EXISTS furina_poll_votes (
+		poll_id    TEXT NOT NULL,
+		voter      TEXT NOT NULL,
+		options    TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (poll_id, voter)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create poll vote table: %v", err)
+	}
+	return nil
+}
+
+// create menyimpan polling baru
+func (ps *pollStore) create(p *pollRecord) error {
+	options, err := json.Marshal(p.Options)
+	if err != nil {
+		return fmt.Errorf("failed to encode poll options: %v", err)
+	}
+
+	var closesAt int64
+	if !p.ClosesAt.IsZero() {
+		closesAt = p.ClosesAt.UnixMilli()
+	}
+
+	_, err = ps.db.Exec(`INSERT INTO furina_polls (id, chat, creator, question, options, selectable, created_at, closes_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.Chat.String(), p.Creator.String(), p.Question, string(options), p.Selectable, p.CreatedAt.UnixMilli(), closesAt)
+	if err != nil {
+		return fmt.Errorf("failed to save poll %s: %v", p.ID, err)
+	}
+	return nil
+}
+
+// get mengambil polling berdasarkan ID pesannya, nil jika tidak ada
+func (ps *pollStore) get(id types.MessageID) (*pollRecord, error) {
+	polls, err := ps.query(`WHERE id = ?`, id)
+	if err != nil || len(polls) == 0 {
+		return nil, err
+	}
+	return polls[0], nil
+}
+
+// latest mengambil polling terbaru di chat, nil jika belum ada
+func (ps *pollStore) latest(chat types.JID) (*pollRecord, error) {
+	polls, err := ps.query(`WHERE chat = ? ORDER BY created_at DESC LIMIT 1`, chat.String())
+	if err != nil || len(polls) == 0 {
+		return nil, err
+	}
+	return polls[0], nil
+}
+
+// due mengambil polling terbuka yang waktu tutup otomatisnya sudah lewat
+func (ps *pollStore) due(now time.Time) ([]*pollRecord, error) {
+	return ps.query(`WHERE closed = 0 AND closes_at > 0 AND closes_at <= ?`, now.UnixMilli())
+}
+
+// query mengambil polling dengan klausa WHERE/ORDER tambahan
+func (ps *pollStore) query(clause string, args ...interface{}) ([]*pollRecord, error) {
+	rows, err := ps.db.Query(`SELECT id, chat, creator, question, options, selectable, created_at, closes_at, closed
+		FROM furina_polls `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load polls: %v", err)
+	}
+	defer rows.Close()
+
+	var polls []*pollRecord
+	for rows.Next() {
+		var chat, creator, options string
+		var createdAt, closesAt int64
+		p := &pollRecord{}
+		if err := rows.Scan(&p.ID, &chat, &creator, &p.Question, &options, &p.Selectable, &createdAt, &closesAt, &p.Closed); err != nil {
+			return nil, fmt.Errorf("failed to read poll: %v", err)
+		}
+		if p.Chat, err = types.ParseJID(chat); err != nil {
+			continue
+		}
+		if p.Creator, err = types.ParseJID(creator); err != nil {
+			continue
+		}
+		if err := json.Unmarshal([]byte(options), &p.Options); err != nil {
+			continue
+		}
+		p.CreatedAt = time.UnixMilli(createdAt)
+		if closesAt > 0 {
+			p.ClosesAt = time.UnixMilli(closesAt)
+		}
+		polls = append(polls, p)
+	}
+	return polls, rows.Err()
+}
+
+// setVote menyimpan pilihan terbaru user. WhatsApp mengirim seluruh pilihan user
+// setiap kali berubah, jadi suara lama diganti; pilihan kosong berarti suara ditarik.
+func (ps *pollStore) setVote(id types.MessageID, voter types.JID, options []string) error {
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("failed to encode poll vote: %v", err)
+	}
+
+	_, err = ps.db.Exec(`INSERT INTO furina_poll_votes (poll_id, voter, options, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (poll_id, voter) DO UPDATE SET options = excluded.options, updated_at = excluded.updated_at`,
+		id, voter.String(), string(encoded), time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save vote for poll %s: %v", id, err)
+	}
+	return nil
+}
+
+// result menghitung suara polling
+func (ps *pollStore) result(p *pollRecord) (*pollResult, error) {
+	rows, err := ps.db.Query(`SELECT options FROM furina_poll_votes WHERE poll_id = ?`, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load votes for poll %s: %v", p.ID, err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(p.Options))
+	for i, option := range p.Options {
+		index[option] = i
+	}
+
+	result := &pollResult{Votes: make([]int, len(p.Options))}
+	for rows.Next() {
+		var encoded string
+		if err := rows.Scan(&encoded); err != nil {
+			return nil, fmt.Errorf("failed to read poll vote: %v", err)
+		}
+		var options []string
+		if err := json.Unmarshal([]byte(encoded), &options); err != nil || len(options) == 0 {
+			continue
+		}
+		result.Voters++
+		for _, option := range options {
+			if i, ok := index[option]; ok {
+				result.Votes[i]++
+			}
+		}
+	}
+	return result, rows.Err()
+}
+
+// close menandai polling ditutup. Mengembalikan false jika polling sudah ditutup
+// sebelumnya, sehingga hasil akhir hanya dikirim sekali.
+func (ps *pollStore) close(id types.MessageID) (bool, error) {
+	res, err := ps.db.Exec(`UPDATE furina_polls SET closed = 1 WHERE id = ? AND closed = 0`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to close poll %s: %v", id, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to close poll %s: %v", id, err)
+	}
+	return affected > 0, nil
+}
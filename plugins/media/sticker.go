@@ -0,0 +1,328 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"strings"
+	"time"
+
+	"furina-bot/lib"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// stickerSize adalah ukuran sisi stiker WhatsApp (piksel)
+const stickerSize = 512
+
+// stickerMaxBytes adalah batas ukuran stiker statis WhatsApp
+const stickerMaxBytes = 100 * 1024
+
+// maxImagePixels adalah batas jumlah piksel gambar yang mau di-decode. Ukuran
+// file saja tidak cukup: PNG kecil bisa mengaku berukuran 50000x50000.
+const maxImagePixels = 4096 * 4096
+
+// defaultStickerPack adalah nama pack default di metadata stiker
+const defaultStickerPack = "Furina Bot"
+
+// stickerStep adalah satu tingkat kompresi stiker: ukuran gambar di dalam kanvas
+// 512x512 dan jumlah bit rendah warna yang dibuang
+type stickerStep struct {
+	size      int
+	posterize uint
+}
+
+// stickerSteps dicoba berurutan sampai stiker muat di stickerMaxBytes. Encoder
+// WebP yang tersedia hanya lossless, jadi ukuran dikecilkan dengan mengurangi
+// variasi warna lalu memperkecil gambar di tengah kanvas.
+var stickerSteps = []stickerStep{
+	{size: stickerSize},
+	{size: stickerSize, posterize: 3},
+	{size: stickerSize, posterize: 4},
+	{size: 448, posterize: 4},
+	{size: 384, posterize: 4},
+	{size: 320, posterize: 4},
+	{size: 256, posterize: 4},
+}
+
+// errImageTooLarge dikembalikan saat dimensi gambar melebihi maxImagePixels
+var errImageTooLarge = errors.New("image dimensions too large")
+
+// StickerPlugin adalah plugin untuk membuat stiker dari gambar dan sebaliknya
+type StickerPlugin struct {
+	pack   string
+	author string
+}
+
+// Pastikan StickerPlugin mengimplementasikan interface Plugin
+var _ lib.Plugin = (*StickerPlugin)(nil)
+
+// NewStickerPlugin membuat instance baru StickerPlugin
+func NewStickerPlugin() *StickerPlugin {
+	return &StickerPlugin{pack: defaultStickerPack}
+}
+
+// GetName mengembalikan nama plugin
+func (p *StickerPlugin) GetName() string {
+	return "sticker"
+}
+
+// GetCommands mengembalikan daftar command yang didukung
+func (p *StickerPlugin) GetCommands() []string {
+	return []string{"sticker", "toimg"}
+}
+
+// GetCommandSpecs mengembalikan metadata command sticker dan toimg
+func (p *StickerPlugin) GetCommandSpecs() []lib.CommandSpec {
+	return []lib.CommandSpec{
+		{
+			Name:        "sticker",
+			Aliases:     []string{"s", "stiker"},
+			Category:    "Media",
+			Description: "Ubah gambar (kirim dengan caption atau balas gambar) menjadi stiker",
+			Flags: []lib.FlagSpec{
+				{Name: "pack", Type: lib.ArgString, Description: "Nama pack stiker"},
+				{Name: "author", Type: lib.ArgString, Description: "Nama pembuat stiker"},
+			},
+			Cooldown: &lib.Cooldown{Duration: 5 * time.Second, Scope: lib.CooldownUser},
+			Timeout:  time.Minute,
+			Examples: []string{"sticker", `sticker --pack "Furina" --author Fahri`},
+		},
+		{
+			Name:        "toimg",
+			Aliases:     []string{"toimage"},
+			Category:    "Media",
+			Description: "Ubah stiker yang dibalas menjadi gambar PNG",
+			Cooldown:    &lib.Cooldown{Duration: 5 * time.Second, Scope: lib.CooldownUser},
+			Timeout:     time.Minute,
+			Examples:    []string{"toimg"},
+		},
+	}
+}
+
+// GetDescription mengembalikan deskripsi plugin
+func (p *StickerPlugin) GetDescription() string {
+	return "Plugin untuk membuat stiker dari gambar dan mengubah stiker menjadi gambar"
+}
+
+// Handle menangani command sticker dan toimg
+func (p *StickerPlugin) Handle(ctx *lib.Context) error {
+	switch ctx.Command.Name {
+	case "sticker":
+		return p.sticker(ctx)
+	case "toimg":
+		return p.toImage(ctx)
+	default:
+		return nil
+	}
+}
+
+// sticker membuat stiker dari gambar di pesan atau gambar yang dibalas
+func (p *StickerPlugin) sticker(ctx *lib.Context) error {
+	media := sourceMedia(ctx)
+	if media == nil || !isImage(media) {
+		if media != nil && (media.Kind == lib.VideoMedia || media.Animated) {
+			return ctx.Reply("❌ Stiker dari video atau GIF belum didukung, gunakan gambar.")
+		}
+		return ctx.Replyf("🖼️ Kirim gambar dengan caption *%s%s* atau balas gambar dengan command ini.", ctx.Prefix, ctx.Invoked)
+	}
+
+	data, err := media.Download(ctx.Ctx)
+	if err != nil {
+		return replyDownloadError(ctx, err)
+	}
+
+	pack, author := p.pack, p.author
+	if ctx.Params.Has("pack") {
+		pack = ctx.Params.String("pack")
+	}
+	if ctx.Params.Has("author") {
+		author = ctx.Params.String("author")
+	} else if author == "" {
+		// Tanpa --author, nama pembuat diambil dari nama WhatsApp pengirim
+		author = ctx.Event.Info.PushName
+	}
+
+	sticker, err := makeSticker(data, pack, author)
+	if errors.Is(err, errImageTooLarge) {
+		return ctx.Reply("❌ Resolusi gambar terlalu besar.")
+	}
+	if err != nil {
+		ctx.Reply("❌ Gambar tidak bisa diubah menjadi stiker.")
+		return err
+	}
+	if len(sticker) > stickerMaxBytes {
+		return ctx.Reply("❌ Gambar terlalu detail untuk dijadikan stiker, coba gambar lain.")
+	}
+	return ctx.SendSticker(sticker, false)
+}
+
+// toImage mengubah stiker yang dibalas menjadi gambar PNG
+func (p *StickerPlugin) toImage(ctx *lib.Context) error {
+	media := sourceMedia(ctx)
+	if media == nil || media.Kind != lib.StickerMedia {
+		return ctx.Reply("🖼️ Balas sebuah stiker dengan command ini.")
+	}
+	if media.Animated {
+		return ctx.Reply("❌ Stiker animasi belum didukung.")
+	}
+
+	data, err := media.Download(ctx.Ctx)
+	if err != nil {
+		return replyDownloadError(ctx, err)
+	}
+
+	if err := checkDimensions(data); err != nil {
+		ctx.Reply("❌ Stiker tidak bisa dibaca.")
+		return err
+	}
+	img, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		ctx.Reply("❌ Stiker tidak bisa dibaca.")
+		return fmt.Errorf("failed to decode sticker: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return fmt.Errorf("failed to encode png: %v", err)
+	}
+	return ctx.SendImage(out.Bytes(), "")
+}
+
+// sourceMedia mengambil media dari pesan command, atau dari pesan yang dibalas
+func sourceMedia(ctx *lib.Context) *lib.Media {
+	if ctx.Media != nil {
+		return ctx.Media
+	}
+	if ctx.Quoted != nil {
+		return ctx.Quoted.Media
+	}
+	return nil
+}
+
+// isImage mengecek apakah media berupa gambar diam, termasuk gambar yang dikirim sebagai dokumen
+func isImage(media *lib.Media) bool {
+	switch media.Kind {
+	case lib.ImageMedia:
+		return true
+	case lib.StickerMedia:
+		return !media.Animated
+	case lib.DocumentMedia:
+		return strings.HasPrefix(media.MimeType, "image/") && media.MimeType != "image/gif"
+	default:
+		return false
+	}
+}
+
+// replyDownloadError membalas user saat media gagal diunduh
+func replyDownloadError(ctx *lib.Context, err error) error {
+	if errors.Is(err, lib.ErrMediaTooLarge) {
+		return ctx.Reply("❌ Ukuran media terlalu besar.")
+	}
+	ctx.Reply("❌ Media gagal diunduh, coba kirim ulang.")
+	return err
+}
+
+// checkDimensions membaca header gambar dan menolak gambar yang terlalu besar
+// sebelum piksel-pikselnya di-decode
+func checkDimensions(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to read image header: %v", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return fmt.Errorf("%w: %dx%d", errImageTooLarge, config.Width, config.Height)
+	}
+	return nil
+}
+
+// makeSticker mengubah gambar (JPEG, PNG, GIF atau WebP) menjadi stiker WebP
+// 512x512 dengan metadata pack di EXIF. Tingkat kompresi di stickerSteps dicoba
+// berurutan; jika tidak ada yang muat, hasil tingkat terakhir dikembalikan dan
+// pemanggil harus mengecek ukurannya.
+func makeSticker(data []byte, pack, author string) ([]byte, error) {
+	if err := checkDimensions(data); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	exif, err := stickerEXIF(pack, author)
+	if err != nil {
+		return nil, err
+	}
+
+	var sticker []byte
+	for _, step := range stickerSteps {
+		canvas := fitSquare(src, step.size)
+		if step.size != stickerSize {
+			canvas = padSquare(canvas, stickerSize)
+		}
+		posterize(canvas, step.posterize)
+
+		var encoded bytes.Buffer
+		if err := nativewebp.Encode(&encoded, canvas, nil); err != nil {
+			return nil, fmt.Errorf("failed to encode webp: %v", err)
+		}
+		if sticker, err = withEXIF(encoded.Bytes(), stickerSize, stickerSize, exif); err != nil {
+			return nil, err
+		}
+		if len(sticker) <= stickerMaxBytes {
+			break
+		}
+	}
+	return sticker, nil
+}
+
+// fitSquare memperkecil atau memperbesar gambar agar muat di kotak size x size
+// dengan rasio tetap, lalu menaruhnya di tengah dengan latar transparan
+func fitSquare(src image.Image, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	targetWidth, targetHeight := size, size
+	if width > height {
+		targetHeight = max(1, height*size/width)
+	} else {
+		targetWidth = max(1, width*size/height)
+	}
+
+	offset := image.Pt((size-targetWidth)/2, (size-targetHeight)/2)
+	target := image.Rectangle{Min: offset, Max: offset.Add(image.Pt(targetWidth, targetHeight))}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(canvas, target, src, bounds, draw.Over, nil)
+	return canvas
+}
+
+// padSquare menaruh gambar di tengah kanvas transparan size x size
+func padSquare(src *image.NRGBA, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	offset := image.Pt((size-bounds.Dx())/2, (size-bounds.Dy())/2)
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Copy(canvas, offset, src, bounds, draw.Src, nil)
+	return canvas
+}
+
+// posterize membuang bits bit terendah setiap kanal warna. Warna yang lebih
+// seragam membuat encoder lossless menghasilkan file yang jauh lebih kecil.
+func posterize(img *image.NRGBA, bits uint) {
+	if bits == 0 {
+		return
+	}
+	mask := uint8(0xff << bits)
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] &= mask
+		img.Pix[i+1] &= mask
+		img.Pix[i+2] &= mask
+	}
+}
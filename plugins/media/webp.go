@@ -0,0 +1,96 @@
+package media
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// vp8xFlagEXIF adalah flag VP8X yang menandakan WebP berisi chunk EXIF
+const vp8xFlagEXIF = 0x08
+
+// stickerEXIF membuat metadata EXIF stiker WhatsApp berisi nama pack dan pembuatnya
+func stickerEXIF(pack, author string) ([]byte, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate sticker pack id: %v", err)
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"sticker-pack-id":        "furina-" + hex.EncodeToString(id),
+		"sticker-pack-name":      pack,
+		"sticker-pack-publisher": author,
+		"emojis":                 []string{""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sticker metadata: %v", err)
+	}
+
+	// Header TIFF little-endian dengan satu entri IFD: tag 0x5741 bertipe UNDEFINED
+	// yang menunjuk ke JSON metadata tepat setelah header (offset 0x16)
+	exif := []byte{
+		0x49, 0x49, 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x41, 0x57, 0x07, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x16, 0x00, 0x00, 0x00,
+	}
+	binary.LittleEndian.PutUint32(exif[14:18], uint32(len(metadata)))
+	return append(exif, metadata...), nil
+}
+
+// withEXIF membungkus WebP sederhana (satu chunk VP8 atau VP8L) menjadi WebP
+// extended (VP8X) dengan chunk EXIF. Flag alpha tidak diisi karena VP8L sudah
+// membawa alpha di bitstream-nya, dan decoder x/image/webp menolak kombinasi itu.
+func withEXIF(webp []byte, width, height int, exif []byte) ([]byte, error) {
+	if len(webp) < 20 || string(webp[0:4]) != "RIFF" || string(webp[8:12]) != "WEBP" {
+		return nil, errors.New("not a WebP image")
+	}
+
+	chunkType := string(webp[12:16])
+	if chunkType != "VP8 " && chunkType != "VP8L" {
+		return nil, fmt.Errorf("unsupported WebP chunk %q", chunkType)
+	}
+	size := int(binary.LittleEndian.Uint32(webp[16:20]))
+	end := 20 + size + size%2
+	if end > len(webp) {
+		end = len(webp)
+	}
+	image := webp[12:end]
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = vp8xFlagEXIF
+	putUint24(vp8x[4:7], width-1)
+	putUint24(vp8x[7:10], height-1)
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	writeChunk(&body, "VP8X", vp8x)
+	body.Write(image)
+	writeChunk(&body, "EXIF", exif)
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// writeChunk menulis satu chunk RIFF beserta padding ke ukuran genap
+func writeChunk(buf *bytes.Buffer, fourCC string, data []byte) {
+	buf.WriteString(fourCC)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+// putUint24 menulis bilangan 24-bit little-endian
+func putUint24(b []byte, v int) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
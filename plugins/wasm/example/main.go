@@ -0,0 +1,71 @@
+//go:build wasip1
+
+// Contoh plugin WebAssembly untuk Furina-Go.
+//
+// Build dengan:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o plugins/wasm/hello.wasm ./plugins/wasm/example
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"unsafe"
+)
+
+//go:wasmimport furina input_len
+func inputLen() uint32
+
+//go:wasmimport furina input_read
+func inputRead(ptr unsafe.Pointer)
+
+//go:wasmimport furina reply
+func reply(ptr unsafe.Pointer, length uint32)
+
+//go:wasmimport furina set_manifest
+func setManifest(ptr unsafe.Pointer, length uint32)
+
+// invocation adalah JSON command yang dikirim bot
+type invocation struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Sender  string   `json:"sender"`
+}
+
+// sendReply membalas pesan dengan teks
+func sendReply(text string) {
+	data := []byte(text)
+	if len(data) == 0 {
+		return
+	}
+	reply(unsafe.Pointer(&data[0]), uint32(len(data)))
+}
+
+//go:wasmexport describe
+func describe() {
+	manifest := []byte(`{"description":"Contoh plugin WebAssembly","commands":[{"name":"hello","aliases":["hai"],"category":"Contoh","description":"Sapa pengirim dari plugin WebAssembly","usage":"[nama]","examples":["hello Furina"]}]}`)
+	setManifest(unsafe.Pointer(&manifest[0]), uint32(len(manifest)))
+}
+
+//go:wasmexport handle
+func handle() {
+	input := make([]byte, inputLen())
+	if len(input) == 0 {
+		return
+	}
+	inputRead(unsafe.Pointer(&input[0]))
+
+	var cmd invocation
+	if err := json.Unmarshal(input, &cmd); err != nil {
+		sendReply("❌ Input tidak valid")
+		return
+	}
+
+	name := "kamu"
+	if len(cmd.Args) > 0 {
+		name = strings.Join(cmd.Args, " ")
+	}
+	sendReply("👋 Halo, " + name + "! Salam dari plugin WebAssembly.")
+}
+
+func main() {}